@@ -0,0 +1,86 @@
+// Package archiver keeps NZBGet history beyond the KeepHistory setting. An Archiver
+// polls the history, including hidden records, and appends new records to a JSON Lines
+// file. Records are deduplicated by NZBID and HistoryTime. The Store answers reporting
+// queries: totals per category, failures per day and the average download speed.
+package archiver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/internal/poll"
+)
+
+// DefaultInterval is how often the Archiver syncs history.
+const DefaultInterval = 10 * time.Minute
+
+// Client is the part of *nzbget.NZBGet the Archiver uses.
+type Client interface {
+	HistoryContext(ctx context.Context, hidden bool) ([]*nzbget.History, error)
+}
+
+// Config is the input data needed to return an Archiver.
+type Config struct {
+	Path     string        // JSON Lines file to write records to.
+	Interval time.Duration // default: DefaultInterval
+	Logger   nzbget.Logger // optional, default: log.Default()
+}
+
+// Archiver copies history records into a Store.
+type Archiver struct {
+	client Client
+	config *Config
+	store  *Store
+}
+
+// New returns an Archiver, and opens its store file.
+func New(client Client, config *Config) (*Archiver, error) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	store, err := OpenStore(config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Archiver{client: client, config: config, store: store}, nil
+}
+
+// Store returns the archive store, for queries.
+func (a *Archiver) Store() *Store {
+	return a.store
+}
+
+// Run syncs history immediately, and then every Interval until the context ends.
+// Errors are logged, and do not stop the archiver.
+func (a *Archiver) Run(ctx context.Context) {
+	poll.Run(ctx, a.config.Interval, a.config.Logger, "history archiver", func(ctx context.Context) error {
+		_, err := a.Sync(ctx)
+		return err
+	})
+}
+
+// Sync archives history records that are not in the store yet, and returns how many were added.
+// Records are written oldest first, so the store stays in history order.
+func (a *Archiver) Sync(ctx context.Context) (int, error) {
+	history, err := a.client.HistoryContext(ctx, true)
+	if err != nil {
+		return 0, fmt.Errorf("getting history: %w", err)
+	}
+
+	// NZBGet returns the newest history first.
+	ordered := make([]*nzbget.History, len(history))
+	for idx, item := range history {
+		ordered[len(history)-1-idx] = item
+	}
+
+	return a.store.Add(ordered)
+}
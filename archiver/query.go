@@ -0,0 +1,124 @@
+package archiver
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// CategoryTotal sums the archived records in one category.
+type CategoryTotal struct {
+	Category string
+	Count    int
+	Failed   int
+	Bytes    int64 // downloaded bytes.
+	Seconds  int64 // download time.
+}
+
+// DayFailures counts the archived records from one day.
+type DayFailures struct {
+	Day    time.Time // midnight, in the location given to Failures.
+	Count  int
+	Failed int
+}
+
+// Failed returns true for history statuses in the FAILURE family, like FAILURE/PAR.
+func Failed(item *nzbget.History) bool {
+	return strings.HasPrefix(item.Status, "FAILURE/")
+}
+
+// Totals returns the count, failures and bytes downloaded per category, sorted by category.
+func (s *Store) Totals(filter *nzbget.HistoryFilter) ([]*CategoryTotal, error) {
+	totals := map[string]*CategoryTotal{}
+
+	err := s.Each(filter, func(item *nzbget.History) bool {
+		total := totals[item.Category]
+		if total == nil {
+			total = &CategoryTotal{Category: item.Category}
+			totals[item.Category] = total
+		}
+
+		total.Count++
+		total.Bytes += nzbget.JoinSize(item.DownloadedSizeHi, item.DownloadedSizeLo)
+		total.Seconds += item.DownloadTimeSec
+
+		if Failed(item) {
+			total.Failed++
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*CategoryTotal, 0, len(totals))
+	for _, total := range totals {
+		output = append(output, total)
+	}
+
+	sort.Slice(output, func(i, j int) bool { return output[i].Category < output[j].Category })
+
+	return output, nil
+}
+
+// Failures returns the count and failures per day, oldest first. Days are split at midnight
+// in loc, or in local time if loc is nil. Days without records are not included.
+func (s *Store) Failures(filter *nzbget.HistoryFilter, loc *time.Location) ([]*DayFailures, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	days := map[time.Time]*DayFailures{}
+
+	err := s.Each(filter, func(item *nzbget.History) bool {
+		t := item.HistoryTime.In(loc)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+		counts := days[day]
+		if counts == nil {
+			counts = &DayFailures{Day: day}
+			days[day] = counts
+		}
+
+		counts.Count++
+
+		if Failed(item) {
+			counts.Failed++
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*DayFailures, 0, len(days))
+	for _, counts := range days {
+		output = append(output, counts)
+	}
+
+	sort.Slice(output, func(i, j int) bool { return output[i].Day.Before(output[j].Day) })
+
+	return output, nil
+}
+
+// AverageSpeed returns the average download speed in bytes per second: the bytes
+// downloaded by the matching records, divided by the time they spent downloading.
+func (s *Store) AverageSpeed(filter *nzbget.HistoryFilter) (int64, error) {
+	var bytes, seconds int64
+
+	err := s.Each(filter, func(item *nzbget.History) bool {
+		bytes += nzbget.JoinSize(item.DownloadedSizeHi, item.DownloadedSizeLo)
+		seconds += item.DownloadTimeSec
+
+		return true
+	})
+	if err != nil || seconds == 0 {
+		return 0, err
+	}
+
+	return bytes / seconds, nil
+}
@@ -0,0 +1,248 @@
+package archiver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golift.io/nzbget"
+)
+
+// maxLine is the longest record accepted from the store file.
+const maxLine = 16 << 20
+
+// ErrBadRecord is returned when the store file has a line that is not a history record.
+var ErrBadRecord = errors.New("invalid record")
+
+// recordKey identifies a history record. Retried downloads get a new HistoryTime, so they are new records.
+type recordKey struct {
+	nzbID int64
+	time  int64
+}
+
+func keyOf(item *nzbget.History) recordKey {
+	return recordKey{nzbID: item.NZBID, time: item.HistoryTime.Unix()}
+}
+
+// Store is a JSON Lines file of history records, one record per line, oldest first.
+type Store struct {
+	path string
+	mu   sync.RWMutex
+	keys map[recordKey]struct{}
+}
+
+// OpenStore opens a store file, and creates it if it does not exist.
+// A partial record left at the end of the file by an interrupted write is removed.
+// Use this to query an archive without syncing it.
+func OpenStore(path string) (*Store, error) {
+	store := &Store{path: path, keys: make(map[recordKey]struct{})}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) //nolint:gomnd
+	if err != nil {
+		return nil, fmt.Errorf("opening store: %w", err)
+	}
+	defer file.Close()
+
+	if err := truncatePartial(file); err != nil {
+		return nil, err
+	}
+
+	err = store.scan(file, func(item *nzbget.History) bool {
+		store.keys[keyOf(item)] = struct{}{}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// truncatePartial removes everything after the last newline in the file.
+func truncatePartial(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("reading store: %w", err)
+	}
+
+	buf := make([]byte, 4096) //nolint:gomnd
+	end := info.Size()
+
+	for offset := end; offset > 0; {
+		size := int64(len(buf))
+		if offset < size {
+			size = offset
+		}
+
+		offset -= size
+
+		if _, err := file.ReadAt(buf[:size], offset); err != nil {
+			return fmt.Errorf("reading store: %w", err)
+		}
+
+		if idx := bytes.LastIndexByte(buf[:size], '\n'); idx >= 0 {
+			end = offset + int64(idx) + 1
+			break
+		}
+
+		end = offset
+	}
+
+	if end == info.Size() {
+		return nil
+	}
+
+	if err := file.Truncate(end); err != nil {
+		return fmt.Errorf("removing partial record: %w", err)
+	}
+
+	return nil
+}
+
+// Path returns the store file path.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Len returns the number of records in the store.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.keys)
+}
+
+// Add appends the records that are not in the store yet, and returns how many were added.
+func (s *Store) Add(history []*nzbget.History) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		buf   bytes.Buffer
+		added = []recordKey{}
+	)
+
+	encoder := json.NewEncoder(&buf)
+
+	for _, item := range history {
+		key := keyOf(item)
+		if _, ok := s.keys[key]; ok {
+			continue
+		}
+
+		if err := encoder.Encode(item); err != nil {
+			return 0, fmt.Errorf("encoding record: %w", err)
+		}
+
+		s.keys[key] = struct{}{}
+		added = append(added, key)
+	}
+
+	if len(added) == 0 {
+		return 0, nil
+	}
+
+	if err := s.write(buf.Bytes()); err != nil {
+		for _, key := range added {
+			delete(s.keys, key)
+		}
+
+		return 0, err
+	}
+
+	return len(added), nil
+}
+
+func (s *Store) write(data []byte) error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600) //nolint:gomnd
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("writing store: %w", err)
+	}
+
+	return nil
+}
+
+// Each calls each for every record that matches the filter, oldest first. A nil filter
+// matches every record. Return false from each to stop reading. The Hidden field of the
+// filter is ignored, because hidden records are archived like the rest.
+func (s *Store) Each(filter *nzbget.HistoryFilter, each func(*nzbget.History) bool) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer file.Close()
+
+	found := 0
+
+	return s.scan(file, func(item *nzbget.History) bool {
+		if filter == nil {
+			return each(item)
+		}
+
+		if !filter.Match(item) {
+			return true
+		}
+
+		found++
+
+		return each(item) && (filter.Limit <= 0 || found < filter.Limit)
+	})
+}
+
+// Records returns the records that match the filter, oldest first.
+func (s *Store) Records(filter *nzbget.HistoryFilter) ([]*nzbget.History, error) {
+	output := []*nzbget.History{}
+	err := s.Each(filter, func(item *nzbget.History) bool {
+		output = append(output, item)
+		return true
+	})
+
+	return output, err
+}
+
+// scan decodes every line in the reader.
+func (s *Store) scan(reader io.Reader, each func(*nzbget.History) bool) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLine)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var item nzbget.History
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return fmt.Errorf("%w: %s line %d: %v", ErrBadRecord, s.path, line, err)
+		}
+
+		if !each(&item) {
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading store: %w", err)
+	}
+
+	return nil
+}
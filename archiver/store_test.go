@@ -0,0 +1,310 @@
+package archiver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// record returns a history record that finished at day 1 of March 2024, plus hours.
+func record(nzbID int64, hours int, category, status string, bytes, seconds int64) *nzbget.History {
+	return &nzbget.History{
+		NZBID:            nzbID,
+		Category:         category,
+		Status:           status,
+		HistoryTime:      nzbget.Time{Time: time.Date(2024, 3, 1, hours, 0, 0, 0, time.UTC)},
+		DownloadedSizeHi: bytes >> 32,
+		DownloadedSizeLo: bytes & 0xFFFFFFFF,
+		DownloadTimeSec:  seconds,
+	}
+}
+
+// sampleStore returns a store in a temp dir with records over two days, in two categories.
+func sampleStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := OpenStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Add([]*nzbget.History{
+		record(1, 1, "tv", "SUCCESS/ALL", 1000, 10),
+		record(2, 2, "tv", "FAILURE/PAR", 500, 5),
+		record(3, 23, "movies", "SUCCESS/UNPACK", 6<<32, 60),
+		record(4, 25, "tv", "FAILURE/HEALTH", 0, 0),
+		record(5, 26, "", "DELETED/MANUAL", 500, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func ids(items []*nzbget.History) []int64 {
+	output := make([]int64, len(items))
+	for idx, item := range items {
+		output[idx] = item.NZBID
+	}
+
+	return output
+}
+
+func sameIDs(got, want []int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+
+	for idx := range got {
+		if got[idx] != want[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestStoreDedupe(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history := []*nzbget.History{record(1, 1, "tv", "SUCCESS/ALL", 0, 0), record(2, 2, "tv", "SUCCESS/ALL", 0, 0)}
+	if added, err := store.Add(history); added != 2 || err != nil {
+		t.Fatalf("got %d added, %v; want 2", added, err)
+	}
+
+	// A retried download has the same NZBID, and a new HistoryTime.
+	history = append(history, record(1, 5, "tv", "FAILURE/PAR", 0, 0))
+	if added, err := store.Add(history); added != 1 || err != nil {
+		t.Fatalf("got %d added, %v; want only the retry", added, err)
+	}
+
+	// Reopening the file keeps the records it already has.
+	if store, err = OpenStore(path); err != nil || store.Len() != 3 {
+		t.Fatalf("reopened store has %d records, %v; want 3", store.Len(), err)
+	}
+
+	if added, err := store.Add(history); added != 0 || err != nil {
+		t.Errorf("got %d added to the reopened store, %v; want 0", added, err)
+	}
+}
+
+func TestOpenStoreTornLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+		want []int64
+	}{
+		{name: "torn last line", want: []int64{1, 2}, data: `{"NZBID":1,"HistoryTime":1709254800}` + "\n" +
+			`{"NZBID":2,"HistoryTime":1709258400}` + "\n" + `{"NZBID":3,"Hist`},
+		{name: "only a torn line", data: `{"NZBID":1,"Hist`, want: []int64{}},
+		{name: "complete", data: `{"NZBID":1,"HistoryTime":1709254800}` + "\n", want: []int64{1}},
+		{name: "empty", data: "", want: []int64{}},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "history.jsonl")
+			if err := os.WriteFile(path, []byte(test.data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			store, err := OpenStore(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// New records start on their own line after the partial one is removed.
+			if _, err := store.Add([]*nzbget.History{record(9, 9, "", "SUCCESS/ALL", 0, 0)}); err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := store.Records(nil)
+			if want := append(test.want, 9); err != nil || !sameIDs(ids(records), want) {
+				t.Errorf("got %v, %v; want %v", ids(records), err, want)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte("not json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenStore(path); !errors.Is(err, ErrBadRecord) {
+		t.Errorf("got %v, want ErrBadRecord", err)
+	}
+}
+
+func TestStoreEach(t *testing.T) {
+	t.Parallel()
+
+	store := sampleStore(t)
+
+	tests := []struct {
+		name   string
+		filter *nzbget.HistoryFilter
+		stop   int // each returns false after this many calls. 0 never stops.
+		want   []int64
+	}{
+		{name: "nil filter", want: []int64{1, 2, 3, 4, 5}},
+		{name: "nil filter stops", stop: 2, want: []int64{1, 2}},
+		{name: "status", filter: &nzbget.HistoryFilter{Statuses: []string{"FAILURE"}}, want: []int64{2, 4}},
+		{name: "limit", filter: &nzbget.HistoryFilter{Categories: []string{"tv"}, Limit: 2}, want: []int64{1, 2}},
+		{name: "limit above matches", filter: &nzbget.HistoryFilter{Categories: []string{"tv"}, Limit: 9}, want: []int64{1, 2, 4}},
+		{name: "stops before limit", filter: &nzbget.HistoryFilter{Limit: 3}, stop: 1, want: []int64{1}},
+		{name: "hidden ignored", filter: &nzbget.HistoryFilter{Hidden: true}, want: []int64{1, 2, 3, 4, 5}},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := []int64{}
+			err := store.Each(test.filter, func(item *nzbget.History) bool {
+				got = append(got, item.NZBID)
+				return test.stop == 0 || len(got) < test.stop
+			})
+
+			if err != nil || !sameIDs(got, test.want) {
+				t.Errorf("got %v, %v; want %v", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestTotals(t *testing.T) {
+	t.Parallel()
+
+	totals, err := sampleStore(t).Totals(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CategoryTotal{
+		{Category: "", Count: 1, Bytes: 500},
+		{Category: "movies", Count: 1, Bytes: 6 << 32, Seconds: 60},
+		{Category: "tv", Count: 3, Failed: 2, Bytes: 1500, Seconds: 15},
+	}
+
+	if len(totals) != len(want) {
+		t.Fatalf("got %d totals, want %d", len(totals), len(want))
+	}
+
+	for idx, total := range totals {
+		if *total != want[idx] {
+			t.Errorf("got %+v, want %+v", *total, want[idx])
+		}
+	}
+}
+
+func TestFailures(t *testing.T) {
+	t.Parallel()
+
+	store := sampleStore(t)
+
+	days, err := store.Failures(nil, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []DayFailures{
+		{Day: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Count: 3, Failed: 1},
+		{Day: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Count: 2, Failed: 1},
+	}
+
+	if len(days) != len(want) {
+		t.Fatalf("got %d days, want %d", len(days), len(want))
+	}
+
+	for idx, day := range days {
+		if !day.Day.Equal(want[idx].Day) || day.Count != want[idx].Count || day.Failed != want[idx].Failed {
+			t.Errorf("got %+v, want %+v", *day, want[idx])
+		}
+	}
+
+	// Two hours east, record 3 at 23:00 UTC moves to the second day.
+	east := time.FixedZone("EET", 2*3600)
+	if days, err = store.Failures(nil, east); err != nil || len(days) != 2 || days[0].Count != 2 || days[1].Count != 3 {
+		t.Errorf("got %d days, %v; want 2 and 3 records", len(days), err)
+	}
+
+	if days[1].Day.Location() != east {
+		t.Errorf("got location %v, want %v", days[1].Day.Location(), east)
+	}
+}
+
+func TestAverageSpeed(t *testing.T) {
+	t.Parallel()
+
+	store := sampleStore(t)
+
+	tests := []struct {
+		name   string
+		filter *nzbget.HistoryFilter
+		want   int64
+	}{
+		{name: "all", want: (6<<32 + 2000) / 75},
+		{name: "tv", filter: &nzbget.HistoryFilter{Categories: []string{"tv"}}, want: 100},
+		{name: "no download time", filter: &nzbget.HistoryFilter{Statuses: []string{"DELETED"}}, want: 0},
+		{name: "no records", filter: &nzbget.HistoryFilter{Categories: []string{"music"}}, want: 0},
+	}
+
+	for _, test := range tests {
+		if speed, err := store.AverageSpeed(test.filter); err != nil || speed != test.want {
+			t.Errorf("%s: got %d, %v; want %d", test.name, speed, err, test.want)
+		}
+	}
+}
+
+type fakeClient struct {
+	history []*nzbget.History
+}
+
+func (f *fakeClient) HistoryContext(context.Context, bool) ([]*nzbget.History, error) {
+	return f.history, nil
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	// NZBGet returns the newest history first.
+	client := &fakeClient{history: []*nzbget.History{record(3, 3, "", "SUCCESS/ALL", 0, 0), record(2, 2, "", "SUCCESS/ALL", 0, 0)}}
+
+	archiver, err := New(client, &Config{Path: filepath.Join(t.TempDir(), "history.jsonl")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if added, err := archiver.Sync(context.Background()); added != 2 || err != nil {
+		t.Fatalf("got %d added, %v; want 2", added, err)
+	}
+
+	client.history = append([]*nzbget.History{record(4, 4, "", "SUCCESS/ALL", 0, 0)}, client.history...)
+	if added, err := archiver.Sync(context.Background()); added != 1 || err != nil {
+		t.Fatalf("got %d added, %v; want 1", added, err)
+	}
+
+	records, err := archiver.Store().Records(nil)
+	if err != nil || !sameIDs(ids(records), []int64{2, 3, 4}) {
+		t.Errorf("got %v, %v; want oldest first", ids(records), err)
+	}
+}
@@ -0,0 +1,160 @@
+package nzbget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// cache stores raw RPC results for a short time, and deduplicates concurrent identical requests.
+type cache struct {
+	ttl     map[string]time.Duration
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	gen     uint64 // incremented when the cache is cleared; results from older generations are not stored.
+}
+
+type cacheEntry struct {
+	done    chan struct{} // closed when the request finishes.
+	result  json.RawMessage
+	err     error
+	expires time.Time
+	waiters int                // callers waiting on the request; protected by cache.mu.
+	cancel  context.CancelFunc // cancels the request once every waiter gives up.
+}
+
+func newCache(ttl map[string]time.Duration) *cache {
+	if len(ttl) == 0 {
+		return nil
+	}
+
+	return &cache{ttl: ttl, entries: make(map[string]*cacheEntry)}
+}
+
+// ClearCache removes every cached response. This is a no-op if the cache is not enabled.
+// The cache is cleared automatically after mutating methods made with this client.
+func (n *NZBGet) ClearCache() {
+	n.cache.clear()
+}
+
+func (c *cache) clear() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.entries = make(map[string]*cacheEntry)
+}
+
+func (c *cache) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(ctx context.Context, call *Call) error {
+		if IsMutating(call.Method) {
+			defer c.clear()
+			return next(ctx, call)
+		}
+
+		ttl := c.ttl[call.Method]
+		if ttl <= 0 {
+			return next(ctx, call)
+		}
+
+		key, err := json.Marshal(call.Args)
+		if err != nil {
+			return next(ctx, call)
+		}
+
+		result, err := c.get(ctx, call.Method+string(key), ttl, func(fetchCtx context.Context, raw *json.RawMessage) error {
+			return next(fetchCtx, &Call{Method: call.Method, Args: call.Args, Output: raw})
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := unmarshalResult(result, call.Output); err != nil {
+			return fmt.Errorf("parsing cached response: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// get returns a cached result, waits for an identical request in flight, or starts fetch.
+// The fetch runs on a context detached from the caller's cancellation, so one caller's
+// deadline does not fail every other caller sharing the request. The fetch is canceled
+// only after every caller waiting on it has given up.
+func (c *cache) get(ctx context.Context, key string, ttl time.Duration, fetch func(context.Context, *json.RawMessage) error) (json.RawMessage, error) {
+	c.mu.Lock()
+
+	if entry := c.entries[key]; entry != nil {
+		select {
+		case <-entry.done:
+			if time.Now().Before(entry.expires) {
+				c.mu.Unlock()
+				return entry.result, nil
+			}
+		default:
+			entry.waiters++
+			c.mu.Unlock()
+
+			return c.wait(ctx, key, entry)
+		}
+	}
+
+	fetchCtx, cancel := context.WithCancel(detached{ctx})
+	entry := &cacheEntry{done: make(chan struct{}), waiters: 1, cancel: cancel}
+	c.entries[key] = entry
+	gen := c.gen
+	c.mu.Unlock()
+
+	go func() {
+		defer cancel()
+
+		entry.err = fetch(fetchCtx, &entry.result)
+		entry.expires = time.Now().Add(ttl)
+
+		c.mu.Lock()
+		if (entry.err != nil || gen != c.gen) && c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+		close(entry.done)
+	}()
+
+	return c.wait(ctx, key, entry)
+}
+
+// wait blocks until the entry's request finishes, or the context ends.
+// The last waiter to give up cancels the request and removes it from the cache.
+func (c *cache) wait(ctx context.Context, key string, entry *cacheEntry) (json.RawMessage, error) {
+	select {
+	case <-entry.done:
+		return entry.result, entry.err
+	case <-ctx.Done():
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry.waiters--; entry.waiters == 0 {
+		entry.cancel()
+
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+	}
+
+	return nil, fmt.Errorf("waiting for shared request: %w", ctx.Err())
+}
+
+// detached carries the values of its parent context, but not its deadline or cancellation.
+type detached struct{ parent context.Context } //nolint:containedctx
+
+func (detached) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detached) Done() <-chan struct{}               { return nil }
+func (detached) Err() error                          { return nil }
+func (d detached) Value(key interface{}) interface{} { return d.parent.Value(key) }
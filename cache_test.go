@@ -0,0 +1,189 @@
+package nzbget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fetcher is a RoundTripFunc returning 42 after release is closed, and counting its calls.
+type fetcher struct {
+	calls   int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func newFetcher() *fetcher {
+	return &fetcher{started: make(chan struct{}, 100), release: make(chan struct{})}
+}
+
+func (f *fetcher) roundTrip(ctx context.Context, call *Call) error {
+	atomic.AddInt32(&f.calls, 1)
+	f.started <- struct{}{}
+
+	select {
+	case <-f.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if raw, ok := call.Output.(*json.RawMessage); ok {
+		*raw = json.RawMessage("42")
+	}
+
+	return nil
+}
+
+func TestCacheShared(t *testing.T) {
+	t.Parallel()
+
+	fetch := newFetcher()
+	rt := newCache(map[string]time.Duration{"status": time.Minute}).middleware(fetch.roundTrip)
+
+	var wg sync.WaitGroup
+
+	results := make([]int, 20)
+	for i := range results {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			if err := rt(context.Background(), &Call{Method: "status", Output: &results[i]}); err != nil {
+				t.Errorf("call %d: %v", i, err)
+			}
+		}(i)
+	}
+
+	<-fetch.started
+	time.Sleep(10 * time.Millisecond) // let the other callers join the request.
+	close(fetch.release)
+	wg.Wait()
+
+	for i, result := range results {
+		if result != 42 {
+			t.Errorf("call %d: got %d, want 42", i, result)
+		}
+	}
+
+	var cached int
+	if err := rt(context.Background(), &Call{Method: "status", Output: &cached}); err != nil || cached != 42 {
+		t.Errorf("cached call: got %d, %v; want 42", cached, err)
+	}
+
+	if calls := atomic.LoadInt32(&fetch.calls); calls != 1 {
+		t.Errorf("got %d requests, want 1", calls)
+	}
+}
+
+// waitForWaiters blocks until n callers are waiting on the request for key.
+func waitForWaiters(t *testing.T, c *cache, key string, n int) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		c.mu.Lock()
+		entry := c.entries[key]
+		joined := entry != nil && entry.waiters == n
+		c.mu.Unlock()
+
+		if joined {
+			return
+		}
+	}
+
+	t.Fatalf("timed out waiting for %d callers to join the request", n)
+}
+
+func TestCacheLeaderDeadline(t *testing.T) {
+	t.Parallel()
+
+	fetch := newFetcher()
+	c := newCache(map[string]time.Duration{"status": time.Minute})
+	rt := c.middleware(fetch.roundTrip)
+	// The leader's context is canceled by hand, after the waiter has joined its request.
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+
+	go func() {
+		var out int
+		leader <- rt(ctx, &Call{Method: "status", Output: &out})
+	}()
+
+	<-fetch.started
+
+	waiter := make(chan error, 1)
+
+	var out int
+
+	go func() { waiter <- rt(context.Background(), &Call{Method: "status", Output: &out}) }()
+
+	waitForWaiters(t, c, "statusnull", 2) // the key is the method and its JSON args.
+	cancel()
+
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Fatalf("leader: got %v, want canceled", err)
+	}
+
+	close(fetch.release)
+
+	if err := <-waiter; err != nil || out != 42 {
+		t.Fatalf("waiter: got %d, %v; want 42", out, err)
+	}
+
+	if calls := atomic.LoadInt32(&fetch.calls); calls != 1 {
+		t.Errorf("got %d requests, want 1", calls)
+	}
+}
+
+func TestCacheAbandoned(t *testing.T) {
+	t.Parallel()
+
+	fetch := newFetcher()
+	rt := newCache(map[string]time.Duration{"status": time.Minute}).middleware(fetch.roundTrip)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+
+	go func() {
+		var out int
+		errs <- rt(ctx, &Call{Method: "status", Output: &out})
+	}()
+
+	<-fetch.started
+	cancel()
+
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want canceled", err)
+	}
+
+	// The abandoned request was canceled and dropped, so this starts a new one.
+	close(fetch.release)
+
+	var out int
+	if err := rt(context.Background(), &Call{Method: "status", Output: &out}); err != nil || out != 42 {
+		t.Fatalf("got %d, %v; want 42", out, err)
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	t.Parallel()
+
+	fetch := newFetcher()
+	close(fetch.release)
+
+	rt := newCache(map[string]time.Duration{"status": time.Minute}).middleware(fetch.roundTrip)
+
+	var out int
+	for _, method := range []string{"status", "status", "rate", "status"} {
+		if err := rt(context.Background(), &Call{Method: method, Output: &out}); err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+	}
+
+	if calls := atomic.LoadInt32(&fetch.calls); calls != 3 {
+		t.Errorf("got %d requests, want 3", calls)
+	}
+}
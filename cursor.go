@@ -0,0 +1,41 @@
+package nzbget
+
+import (
+	"time"
+
+	"golift.io/nzbget/internal/list"
+)
+
+// HistoryCursor remembers the newest history items seen, so new items can be found between polls.
+// It can be saved as JSON to remember its place between restarts.
+type HistoryCursor struct {
+	Since time.Time `json:"since"`    // HistoryTime of the newest item seen.
+	IDs   []int64   `json:"sinceIds"` // NZBIDs seen with a HistoryTime of exactly Since.
+}
+
+// Advance returns the history items not seen before, and moves the cursor past them.
+// History items are matched by HistoryTime and NZBID, so retried downloads are new again.
+func (c *HistoryCursor) Advance(history []*History) []*History {
+	since, ids := c.Since, c.IDs
+	output := []*History{}
+
+	for _, item := range history {
+		switch {
+		case item.HistoryTime.Before(since), item.HistoryTime.Equal(since) && list.Contains(ids, item.NZBID):
+			continue
+		case item.HistoryTime.After(c.Since):
+			c.Since, c.IDs = item.HistoryTime.Time, []int64{item.NZBID}
+		case item.HistoryTime.Equal(c.Since):
+			c.IDs = append(c.IDs, item.NZBID)
+		}
+
+		output = append(output, item)
+	}
+
+	return output
+}
+
+// IsZero returns true if the cursor has not seen any history.
+func (c *HistoryCursor) IsZero() bool {
+	return c.Since.IsZero()
+}
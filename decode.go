@@ -0,0 +1,138 @@
+package nzbget
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+// Errors returned while decoding responses.
+var (
+	// ErrNullResult is returned when a response has a null result. It is the same value as
+	// gorilla's json2.ErrNullResult, so errors.Is matches either one.
+	ErrNullResult      = json2.ErrNullResult
+	ErrInvalidResponse = errors.New("invalid response")
+	// errStopStream is returned by streams to stop reading a response early.
+	errStopStream = errors.New("stop stream")
+)
+
+// RPCError is returned when NZBGet responds to a request with an error.
+type RPCError struct {
+	Name    string `json:"name"`
+	Code    int64  `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error satisfies the error interface.
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s %d: %s", e.Name, e.Code, e.Message)
+}
+
+// resultDecoder is implemented by outputs that read the RPC result from the response stream themselves.
+// This allows large results to be processed one element at a time, and abandoned early.
+type resultDecoder interface {
+	// decodeResult reads the result value from dec. It returns ErrNullResult for
+	// a null result, and errStopStream if it stopped before reading the whole result.
+	decodeResult(dec *json.Decoder) error
+}
+
+// resultValue decodes a result into output the same way json.Unmarshal would, but remembers null results.
+type resultValue struct {
+	output interface{}
+	null   bool
+}
+
+func (r *resultValue) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		r.null = true
+		return nil
+	}
+
+	return json.Unmarshal(data, &r.output) //nolint:wrapcheck // wrapped by decodeResponse.
+}
+
+// decodeResponse reads a JSON-RPC response and decodes the result directly into output.
+// Streams that stop early leave the rest of the response unread.
+func decodeResponse(body io.Reader, output interface{}) error {
+	dec := json.NewDecoder(body)
+
+	if token, err := dec.Token(); err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	} else if token != json.Delim('{') {
+		return fmt.Errorf("%w: unexpected %v", ErrInvalidResponse, token)
+	}
+
+	gotResult := false
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("reading response: %w", err)
+		}
+
+		switch token {
+		case "result":
+			if gotResult, err = decodeResult(dec, output); err != nil {
+				if errors.Is(err, errStopStream) {
+					return nil
+				}
+
+				return err
+			}
+		case "error":
+			var rpcErr *RPCError
+			if err := dec.Decode(&rpcErr); err != nil {
+				return fmt.Errorf("decoding error: %w", err)
+			} else if rpcErr != nil {
+				return rpcErr
+			}
+		default:
+			if err := dec.Decode(&json.RawMessage{}); err != nil {
+				return fmt.Errorf("reading response: %w", err)
+			}
+		}
+	}
+
+	if !gotResult {
+		return ErrNullResult
+	}
+
+	return nil
+}
+
+// decodeResult decodes the next value from dec into output, and returns false if it was null.
+func decodeResult(dec *json.Decoder, output interface{}) (bool, error) {
+	if stream, ok := output.(resultDecoder); ok {
+		err := stream.decodeResult(dec)
+		if errors.Is(err, ErrNullResult) {
+			return false, nil
+		}
+
+		return err == nil || errors.Is(err, errStopStream), err
+	}
+
+	result := &resultValue{output: output}
+	if err := dec.Decode(result); err != nil {
+		return false, fmt.Errorf("decoding result: %w", err)
+	}
+
+	return !result.null, nil
+}
+
+// unmarshalResult decodes a raw result into output. Used for results that are already buffered.
+func unmarshalResult(data []byte, output interface{}) error {
+	gotResult, err := decodeResult(json.NewDecoder(bytes.NewReader(data)), output)
+	if errors.Is(err, errStopStream) {
+		return nil
+	} else if err != nil {
+		return err
+	} else if !gotResult {
+		return ErrNullResult
+	}
+
+	return nil
+}
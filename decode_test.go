@@ -0,0 +1,132 @@
+package nzbget
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/gorilla/rpc/json"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+// historyResponse returns a JSON-RPC response body with count synthetic history items.
+func historyResponse(count int) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(`{"version":"1.1","result":[`)
+
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		fmt.Fprintf(&buf, `{"NZBID":%[1]d,"Name":"Some.Download.Name.%[1]d","NZBName":"Some.Download.Name.%[1]d",`+
+			`"Kind":"NZB","HistoryTime":1700000000,"Status":"SUCCESS/ALL","Category":"tv",`+
+			`"NZBFilename":"Some.Download.Name.%[1]d.nzb","DestDir":"/downloads/tv/Some.Download.Name.%[1]d",`+
+			`"FinalDir":"","ParStatus":"SUCCESS","UnpackStatus":"SUCCESS","MoveStatus":"SUCCESS",`+
+			`"ScriptStatus":"SUCCESS","DeleteStatus":"NONE","MarkStatus":"NONE","UrlStatus":"NONE",`+
+			`"FileSizeLo":1234567890,"FileSizeHi":1,"FileSizeMB":5273,"FileCount":120,`+
+			`"MinPostTime":1690000000,"MaxPostTime":1690000100,"TotalArticles":7000,"SuccessArticles":7000,`+
+			`"Health":1000,"CriticalHealth":950,"DownloadedSizeLo":1234567890,"DownloadedSizeHi":1,`+
+			`"DownloadedSizeMB":5273,"DownloadTimeSec":600,"PostTotalTimeSec":120,"UnpackTimeSec":60,`+
+			`"Parameters":[{"Name":"*Unpack:","Value":"yes"}],`+
+			`"ScriptStatuses":[{"Name":"Notify.py","Status":"SUCCESS"}],`+
+			`"ServerStats":[{"ServerID":1,"SuccessArticles":7000,"FailedArticles":0}]}`, i+1)
+	}
+
+	buf.WriteString(`],"error":null}`)
+
+	return buf.Bytes()
+}
+
+// BenchmarkGetIntoBuffered decodes the history the way GetInto did before streaming:
+// the whole body is read into memory, then unmarshaled into a slice.
+func BenchmarkGetIntoBuffered(b *testing.B) {
+	body := historyResponse(5000)
+
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		data, err := io.ReadAll(bytes.NewReader(body))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var output []*History
+		if err := json.DecodeClientResponse(bytes.NewReader(data), &output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetIntoStreaming decodes the history into a slice with the streaming decoder GetInto uses.
+func BenchmarkGetIntoStreaming(b *testing.B) {
+	body := historyResponse(5000)
+
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var output []*History
+		if err := decodeResponse(bytes.NewReader(body), &output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetIntoEach decodes the history one item at a time, as HistoryEach does.
+func BenchmarkGetIntoEach(b *testing.B) {
+	body := historyResponse(5000)
+
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		count := 0
+		stream := &listStream[History]{each: func(*History) bool { count++; return true }}
+
+		if err := decodeResponse(bytes.NewReader(body), stream); err != nil || count != 5000 {
+			b.Fatalf("decoded %d items: %v", count, err)
+		}
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	t.Parallel()
+
+	var output []*History
+	if err := decodeResponse(bytes.NewReader(historyResponse(3)), &output); err != nil {
+		t.Fatal(err)
+	} else if len(output) != 3 || output[2].NZBID != 3 || output[0].HistoryTime.Unix() != 1700000000 {
+		t.Fatalf("unexpected output: %d items", len(output))
+	}
+
+	body := `{"version":"1.1","result":null,"error":{"name":"JsonRpcError","code":1,"message":"Invalid procedure"}}`
+
+	var rpcErr *RPCError
+	if err := decodeResponse(bytes.NewReader([]byte(body)), &output); !errors.As(err, &rpcErr) || rpcErr.Code != 1 {
+		t.Fatalf("expected RPCError, got: %v", err)
+	}
+
+	var version string
+
+	err := decodeResponse(bytes.NewReader([]byte(`{"version":"1.1","result":null}`)), &version)
+	if !errors.Is(err, ErrNullResult) || !errors.Is(err, json2.ErrNullResult) {
+		t.Fatalf("expected a null result to match both ErrNullResult values, got: %v", err)
+	}
+
+	nullList := &listStream[History]{each: func(*History) bool { return true }}
+	if err := decodeResponse(bytes.NewReader([]byte(`{"version":"1.1","result":null}`)), nullList); !errors.Is(err, json2.ErrNullResult) {
+		t.Fatalf("expected a null list to match json2.ErrNullResult, got: %v", err)
+	}
+
+	stopped := 0
+	stream := &listStream[History]{each: func(*History) bool { stopped++; return false }}
+
+	if err := decodeResponse(bytes.NewReader(historyResponse(3)), stream); err != nil || stopped != 1 {
+		t.Fatalf("stopped stream: decoded %d items: %v", stopped, err)
+	}
+}
@@ -0,0 +1,304 @@
+// Package diskguard pauses NZBGet downloads before the download volume fills up,
+// and resumes them when space recovers. It projects free space from the Status
+// free disk space and the remaining size of every unpaused download in the queue.
+package diskguard
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/internal/poll"
+)
+
+// DefaultInterval is how often the Guard checks free space.
+const DefaultInterval = time.Minute
+
+// Mode determines what the Guard pauses.
+type Mode int
+
+// Modes go here.
+const (
+	PauseAll    Mode = iota // pause all downloads with PauseDownload.
+	PauseGroups             // pause the largest groups with GroupPause until enough space is projected.
+)
+
+// EventType identifies what the Guard did.
+type EventType string
+
+// EventTypes go here.
+const (
+	EventPaused        EventType = "PAUSED"         // downloads were paused.
+	EventResumed       EventType = "RESUMED"        // downloads were resumed.
+	EventGroupsPaused  EventType = "GROUPS_PAUSED"  // groups were paused.
+	EventGroupsResumed EventType = "GROUPS_RESUMED" // groups were resumed.
+	EventError         EventType = "ERROR"          // an action failed.
+)
+
+// Client is the part of *nzbget.NZBGet the Guard uses.
+type Client interface {
+	StatusContext(ctx context.Context) (*nzbget.Status, error)
+	ListGroupsContext(ctx context.Context) ([]*nzbget.Group, error)
+	PauseDownloadContext(ctx context.Context) (bool, error)
+	ResumeDownloadContext(ctx context.Context) (bool, error)
+	EditQueueContext(ctx context.Context, command, parameter string, ids []int64) (bool, error)
+}
+
+// Config is the input data needed to return a Guard.
+//
+//nolint:lll
+type Config struct {
+	MinFreeMB    int64         `json:"minFreeMb"    toml:"min_free_mb"   xml:"min_free_mb"   yaml:"minFreeMb"`      // pause when projected free space drops below this.
+	ResumeFreeMB int64         `json:"resumeFreeMb" toml:"resume_free_mb" xml:"resume_free_mb" yaml:"resumeFreeMb"` // resume when projected free space reaches this, default: MinFreeMB.
+	UnpackFactor float64       `json:"unpackFactor" toml:"unpack_factor" xml:"unpack_factor" yaml:"unpackFactor"`   // multiplies remaining sizes to account for unpacking, default: 1.
+	Mode         Mode          `json:"mode"         toml:"mode"          xml:"mode"          yaml:"mode"`
+	Interval     time.Duration `json:"interval"     toml:"interval"      xml:"interval"      yaml:"interval"` // default: DefaultInterval
+	OnEvent      func(*Event)  `json:"-"            toml:"-"             xml:"-"             yaml:"-"`        // optional, for alerting.
+	Logger       nzbget.Logger `json:"-"            toml:"-"             xml:"-"             yaml:"-"`        // optional, default: log.Default()
+}
+
+// Event describes an action taken by the Guard.
+type Event struct {
+	Type        EventType
+	FreeMB      int64   // free disk space reported by NZBGet.
+	ProjectedMB int64   // free disk space after unpaused downloads finish.
+	NZBIDs      []int64 // groups paused or resumed, for group events.
+	Error       error   // for EventError.
+}
+
+// Guard watches free disk space and pauses downloads when it runs low.
+// Only downloads paused by the Guard are resumed by it.
+type Guard struct {
+	client    Client
+	config    *Config
+	mu        sync.Mutex
+	pausedAll bool
+	paused    map[int64]bool // groups paused by the guard.
+}
+
+// New returns a Guard.
+func New(client Client, config *Config) *Guard {
+	if config.ResumeFreeMB < config.MinFreeMB {
+		config.ResumeFreeMB = config.MinFreeMB
+	}
+
+	if config.UnpackFactor <= 0 {
+		config.UnpackFactor = 1
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	return &Guard{client: client, config: config, paused: make(map[int64]bool)}
+}
+
+// Run checks free space immediately, and then every Interval until the context ends.
+// Errors are logged, and do not stop the guard.
+func (g *Guard) Run(ctx context.Context) {
+	poll.Run(ctx, g.config.Interval, g.config.Logger, "disk guard", g.Check)
+}
+
+// Check projects free disk space, and pauses or resumes downloads as needed.
+func (g *Guard) Check(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	status, err := g.client.StatusContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting status: %w", err)
+	}
+
+	groups, err := g.client.ListGroupsContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting groups: %w", err)
+	}
+
+	event := &Event{FreeMB: status.FreeDiskSpaceMB, ProjectedMB: status.FreeDiskSpaceMB - g.needMB(groups)}
+
+	switch {
+	case event.ProjectedMB < g.config.MinFreeMB && g.config.Mode == PauseGroups:
+		return g.pauseGroups(ctx, event, groups)
+	case event.ProjectedMB < g.config.MinFreeMB && !status.DownloadPaused:
+		return g.pauseAll(ctx, event)
+	case event.ProjectedMB < g.config.ResumeFreeMB:
+		return nil
+	case g.pausedAll && status.DownloadPaused:
+		return g.resumeAll(ctx, event)
+	case len(g.paused) > 0:
+		return g.resumeGroups(ctx, event, groups)
+	default:
+		g.pausedAll = g.pausedAll && status.DownloadPaused
+		return nil
+	}
+}
+
+func (g *Guard) pauseAll(ctx context.Context, event *Event) error {
+	if _, err := g.client.PauseDownloadContext(ctx); err != nil {
+		return g.fail(event, fmt.Errorf("pausing downloads: %w", err))
+	}
+
+	g.pausedAll = true
+	event.Type = EventPaused
+	g.emit(event)
+
+	return nil
+}
+
+func (g *Guard) resumeAll(ctx context.Context, event *Event) error {
+	if _, err := g.client.ResumeDownloadContext(ctx); err != nil {
+		return g.fail(event, fmt.Errorf("resuming downloads: %w", err))
+	}
+
+	g.pausedAll = false
+	event.Type = EventResumed
+	g.emit(event)
+
+	return nil
+}
+
+// pauseGroups pauses the largest unpaused groups until the projected free space is enough.
+func (g *Guard) pauseGroups(ctx context.Context, event *Event, groups []*nzbget.Group) error {
+	candidates := g.unpaused(groups)
+	sort.Slice(candidates, func(i, j int) bool { return g.sizeMB(candidates[i]) > g.sizeMB(candidates[j]) })
+
+	for _, group := range candidates {
+		if event.ProjectedMB >= g.config.MinFreeMB {
+			break
+		}
+
+		event.ProjectedMB += g.sizeMB(group)
+		event.NZBIDs = append(event.NZBIDs, group.NZBID)
+	}
+
+	if len(event.NZBIDs) == 0 {
+		return nil
+	}
+
+	if _, err := g.client.EditQueueContext(ctx, "GroupPause", "", event.NZBIDs); err != nil {
+		return g.fail(event, fmt.Errorf("pausing groups: %w", err))
+	}
+
+	for _, id := range event.NZBIDs {
+		g.paused[id] = true
+	}
+
+	event.Type = EventGroupsPaused
+	g.emit(event)
+
+	return nil
+}
+
+// resumeGroups resumes the smallest groups paused by the guard while the projected free space stays enough.
+func (g *Guard) resumeGroups(ctx context.Context, event *Event, groups []*nzbget.Group) error {
+	candidates := []*nzbget.Group{}
+	queued := make(map[int64]bool, len(groups))
+
+	for _, group := range groups {
+		queued[group.NZBID] = true
+
+		if g.paused[group.NZBID] && group.Status == nzbget.GroupPAUSED {
+			candidates = append(candidates, group)
+		}
+	}
+
+	for id := range g.paused {
+		if !queued[id] {
+			delete(g.paused, id) // deleted or finished.
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return g.sizeMB(candidates[i]) < g.sizeMB(candidates[j]) })
+
+	for _, group := range candidates {
+		if event.ProjectedMB-g.sizeMB(group) < g.config.ResumeFreeMB {
+			break
+		}
+
+		event.ProjectedMB -= g.sizeMB(group)
+		event.NZBIDs = append(event.NZBIDs, group.NZBID)
+	}
+
+	if len(event.NZBIDs) == 0 {
+		return nil
+	}
+
+	if _, err := g.client.EditQueueContext(ctx, "GroupResume", "", event.NZBIDs); err != nil {
+		return g.fail(event, fmt.Errorf("resuming groups: %w", err))
+	}
+
+	for _, id := range event.NZBIDs {
+		delete(g.paused, id)
+	}
+
+	event.Type = EventGroupsResumed
+	g.emit(event)
+
+	return nil
+}
+
+// needMB returns the space the unpaused groups still need.
+func (g *Guard) needMB(groups []*nzbget.Group) int64 {
+	need := int64(0)
+	for _, group := range g.unpaused(groups) {
+		need += g.sizeMB(group)
+	}
+
+	return need
+}
+
+// sizeMB returns the space a group needs while it downloads, including unpacking.
+// Paused files in a downloading group are not counted. A paused group counts all
+// of its remaining size, because resuming the group resumes every file in it.
+// Pausing and resuming both use this, so a group is weighed the same either way.
+func (g *Guard) sizeMB(group *nzbget.Group) int64 {
+	remaining := group.RemainingSizeMB
+	if group.Status != nzbget.GroupPAUSED {
+		remaining -= group.PausedSizeMB
+	}
+
+	if remaining < 0 {
+		return 0
+	}
+
+	return int64(float64(remaining) * g.config.UnpackFactor)
+}
+
+func (g *Guard) unpaused(groups []*nzbget.Group) []*nzbget.Group {
+	output := []*nzbget.Group{}
+
+	for _, group := range groups {
+		if group.Status != nzbget.GroupPAUSED && g.sizeMB(group) > 0 {
+			output = append(output, group)
+		}
+	}
+
+	return output
+}
+
+func (g *Guard) emit(event *Event) {
+	g.config.Logger.Printf("disk guard: %s, free: %d MB, projected: %d MB, groups: %v",
+		event.Type, event.FreeMB, event.ProjectedMB, event.NZBIDs)
+
+	if g.config.OnEvent != nil {
+		g.config.OnEvent(event)
+	}
+}
+
+// fail emits an error event and returns the error.
+func (g *Guard) fail(event *Event, err error) error {
+	event.Type, event.Error = EventError, err
+	if g.config.OnEvent != nil {
+		g.config.OnEvent(event)
+	}
+
+	return err
+}
@@ -0,0 +1,234 @@
+package diskguard
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+
+	"golift.io/nzbget"
+)
+
+// fakeClient is an NZBGet queue that records the commands the Guard sends.
+type fakeClient struct {
+	status   nzbget.Status
+	groups   []*nzbget.Group
+	commands []string
+}
+
+func (f *fakeClient) StatusContext(context.Context) (*nzbget.Status, error) {
+	status := f.status
+	return &status, nil
+}
+
+func (f *fakeClient) ListGroupsContext(context.Context) ([]*nzbget.Group, error) {
+	return f.groups, nil
+}
+
+func (f *fakeClient) PauseDownloadContext(context.Context) (bool, error) {
+	f.commands = append(f.commands, "pausedownload")
+	f.status.DownloadPaused = true
+
+	return true, nil
+}
+
+func (f *fakeClient) ResumeDownloadContext(context.Context) (bool, error) {
+	f.commands = append(f.commands, "resumedownload")
+	f.status.DownloadPaused = false
+
+	return true, nil
+}
+
+// EditQueueContext pauses and resumes groups like NZBGet: pausing a group pauses all of its files.
+func (f *fakeClient) EditQueueContext(_ context.Context, command, _ string, ids []int64) (bool, error) {
+	f.commands = append(f.commands, command)
+
+	for _, id := range ids {
+		for _, group := range f.groups {
+			switch {
+			case group.NZBID != id:
+			case command == "GroupPause":
+				group.Status, group.PausedSizeMB = nzbget.GroupPAUSED, group.RemainingSizeMB
+			case command == "GroupResume":
+				group.Status, group.PausedSizeMB = nzbget.GroupQUEUED, 0
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func group(id, sizeMB int64) *nzbget.Group {
+	return &nzbget.Group{NZBID: id, Status: nzbget.GroupQUEUED, RemainingSizeMB: sizeMB}
+}
+
+// check runs one Guard check with freeMB free, and returns the event it emitted, if any.
+func check(t *testing.T, guard *Guard, client *fakeClient, freeMB int64) *Event {
+	t.Helper()
+
+	var event *Event
+
+	guard.config.OnEvent = func(e *Event) { event = e }
+	client.status.FreeDiskSpaceMB = freeMB
+
+	if err := guard.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return event
+}
+
+func sameIDs(got, want []int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+
+	for idx := range got {
+		if got[idx] != want[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestPauseAllHysteresis(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{groups: []*nzbget.Group{group(1, 500)}}
+	guard := New(client, &Config{MinFreeMB: 1000, ResumeFreeMB: 2000, Logger: log.New(io.Discard, "", 0)})
+
+	steps := []struct {
+		freeMB int64
+		event  EventType // empty for no event.
+		paused bool
+	}{
+		{freeMB: 2000, paused: false},                      // projected 1500.
+		{freeMB: 1400, event: EventPaused, paused: true},   // projected 900, below MinFreeMB.
+		{freeMB: 1300, paused: true},                       // still low, already paused.
+		{freeMB: 2300, paused: true},                       // projected 1800, between the thresholds.
+		{freeMB: 2500, event: EventResumed, paused: false}, // projected 2000, reached ResumeFreeMB.
+		{freeMB: 2300, paused: false},                      // between the thresholds, stays resumed.
+		{freeMB: 1499, event: EventPaused, paused: true},   // projected 999.
+	}
+
+	for idx, step := range steps {
+		event := check(t, guard, client, step.freeMB)
+
+		if step.event == "" && event != nil {
+			t.Errorf("step %d: unexpected event %s", idx, event.Type)
+		} else if step.event != "" && (event == nil || event.Type != step.event) {
+			t.Errorf("step %d: got event %v, want %s", idx, event, step.event)
+		}
+
+		if client.status.DownloadPaused != step.paused {
+			t.Errorf("step %d: got paused %v, want %v", idx, client.status.DownloadPaused, step.paused)
+		}
+	}
+}
+
+func TestUserPausedDownloads(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{groups: []*nzbget.Group{group(1, 500)}}
+	client.status.DownloadPaused = true // paused by the user.
+	guard := New(client, &Config{MinFreeMB: 1000, Logger: log.New(io.Discard, "", 0)})
+
+	if event := check(t, guard, client, 10000); event != nil || len(client.commands) != 0 {
+		t.Fatalf("got event %v, commands %v; the user's pause must be left alone", event, client.commands)
+	}
+
+	// A group the user paused is not resumed either, even after the guard resumes its own.
+	client = &fakeClient{groups: []*nzbget.Group{group(1, 800), group(2, 100)}}
+	client.groups[1].Status, client.groups[1].PausedSizeMB = nzbget.GroupPAUSED, 100
+	guard = New(client, &Config{MinFreeMB: 1000, Mode: PauseGroups, Logger: log.New(io.Discard, "", 0)})
+
+	if event := check(t, guard, client, 1500); event == nil || !sameIDs(event.NZBIDs, []int64{1}) {
+		t.Fatalf("got %v, want group 1 paused", event)
+	}
+
+	if event := check(t, guard, client, 10000); event == nil || !sameIDs(event.NZBIDs, []int64{1}) {
+		t.Fatalf("got %v, want only group 1 resumed", event)
+	}
+
+	if client.groups[1].Status != nzbget.GroupPAUSED {
+		t.Error("the group paused by the user was resumed")
+	}
+}
+
+func TestPauseGroupsLargestFirst(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{groups: []*nzbget.Group{group(1, 100), group(2, 800), group(3, 300)}}
+	// A downloading group with paused files only needs the unpaused part.
+	client.groups[2].PausedSizeMB = 100
+	guard := New(client, &Config{MinFreeMB: 1000, Mode: PauseGroups, Logger: log.New(io.Discard, "", 0)})
+
+	// Needs 1100 MB, so 1500 MB free projects 400 MB. Pausing group 2 frees enough.
+	event := check(t, guard, client, 1500)
+	if event == nil || event.Type != EventGroupsPaused || !sameIDs(event.NZBIDs, []int64{2}) || event.ProjectedMB != 1200 {
+		t.Fatalf("got %+v, want group 2 paused with 1200 MB projected", event)
+	}
+
+	// Projecting 100 MB needs groups 2 and 3 paused, largest first.
+	client.groups[1].Status, client.groups[1].PausedSizeMB = nzbget.GroupQUEUED, 0
+	guard.paused = make(map[int64]bool)
+
+	if event = check(t, guard, client, 1200); event == nil || !sameIDs(event.NZBIDs, []int64{2, 3}) {
+		t.Fatalf("got %+v, want groups 2 and 3 paused", event)
+	}
+}
+
+func TestResumeGroupsSmallestFirst(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{groups: []*nzbget.Group{group(1, 500), group(2, 400), group(3, 200)}}
+	guard := New(client, &Config{MinFreeMB: 1000, ResumeFreeMB: 1500, Mode: PauseGroups, Logger: log.New(io.Discard, "", 0)})
+
+	// Needs 1100 MB, so 1200 MB free projects 100 MB. Groups 1 and 2 are paused.
+	if event := check(t, guard, client, 1200); event == nil || !sameIDs(event.NZBIDs, []int64{1, 2}) {
+		t.Fatalf("got %+v, want groups 1 and 2 paused", event)
+	}
+
+	// Group 3 needs 200 MB, so this projects 1300 MB: under ResumeFreeMB.
+	if event := check(t, guard, client, 1500); event != nil {
+		t.Fatalf("got %+v, want nothing resumed below ResumeFreeMB", event)
+	}
+
+	// Projects 2000 MB: resuming group 2 leaves 1600 MB, and group 1 would leave 1100 MB.
+	event := check(t, guard, client, 2200)
+	if event == nil || event.Type != EventGroupsResumed || !sameIDs(event.NZBIDs, []int64{2}) || event.ProjectedMB != 1600 {
+		t.Fatalf("got %+v, want group 2 resumed with 1600 MB projected", event)
+	}
+
+	// Projects 2000 MB again with group 2 downloading, so group 1 is resumed.
+	if event = check(t, guard, client, 2600); event == nil || !sameIDs(event.NZBIDs, []int64{1}) {
+		t.Fatalf("got %+v, want group 1 resumed", event)
+	}
+
+	if len(guard.paused) != 0 {
+		t.Errorf("the guard still tracks paused groups: %v", guard.paused)
+	}
+}
+
+func TestForgetDeletedGroups(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{groups: []*nzbget.Group{group(1, 500), group(2, 400), group(3, 200)}}
+	guard := New(client, &Config{MinFreeMB: 1000, Mode: PauseGroups, Logger: log.New(io.Discard, "", 0)})
+
+	if event := check(t, guard, client, 1200); event == nil || !sameIDs(event.NZBIDs, []int64{1, 2}) {
+		t.Fatalf("got %+v, want groups 1 and 2 paused", event)
+	}
+
+	// Group 1 is deleted from the queue while paused.
+	client.groups = client.groups[1:]
+
+	if event := check(t, guard, client, 5000); event == nil || !sameIDs(event.NZBIDs, []int64{2}) {
+		t.Fatalf("got %+v, want group 2 resumed", event)
+	}
+
+	if len(guard.paused) != 0 {
+		t.Errorf("the guard still tracks groups: %v", guard.paused)
+	}
+}
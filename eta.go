@@ -0,0 +1,129 @@
+package nzbget
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSmoothing is the weight given to each new download rate sample by an Estimator.
+const DefaultSmoothing = 0.3
+
+// Estimator computes download ETAs for the queue from a smoothed download rate.
+// Feed it Status samples with AddSample, then call Estimate. It is safe for concurrent use.
+type Estimator struct {
+	smoothing float64
+	mu        sync.Mutex
+	rate      float64 // bytes per second.
+}
+
+// ItemETA is the estimated download window for one queued group.
+type ItemETA struct {
+	Group  *Group
+	Start  time.Time // When the group should start downloading.
+	Finish time.Time // When the group should finish downloading.
+	// Paused is true if the group, or the whole queue, will not download until resumed.
+	// Start and Finish are zero for paused groups.
+	Paused bool
+	// Unknown is true if the group will download, but there is no download rate to
+	// estimate when. Start and Finish are zero for unknown groups.
+	Unknown bool
+}
+
+// QueueETA is the estimated download schedule for the whole queue.
+type QueueETA struct {
+	Rate      int64         // Bytes per second used for the estimate.
+	Items     []*ItemETA    // Groups in the order NZBGet will download them.
+	Finish    time.Time     // When the last unpaused group should finish downloading.
+	Remaining time.Duration // Time until Finish.
+	Unknown   bool          // True if any unpaused group has no estimate, because the rate is 0.
+}
+
+// NewEstimator returns an Estimator. Smoothing is the weight, from 0 to 1, given to each
+// new rate sample; lower values react slower to rate changes. Use 0 for DefaultSmoothing.
+func NewEstimator(smoothing float64) *Estimator {
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = DefaultSmoothing
+	}
+
+	return &Estimator{smoothing: smoothing}
+}
+
+// AddSample adds the current download rate from a Status to the smoothed rate.
+// Samples taken while downloads are paused are ignored. The first sample
+// uses the average download rate, because the current rate is often 0.
+func (e *Estimator) AddSample(status *Status) {
+	if status.DownloadPaused {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.rate == 0 {
+		e.rate = float64(status.AverageDownloadRate)
+	}
+
+	e.rate = e.smoothing*float64(status.DownloadRate) + (1-e.smoothing)*e.rate
+}
+
+// Rate returns the smoothed download rate in bytes per second.
+func (e *Estimator) Rate() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return int64(e.rate)
+}
+
+// Estimate returns ETAs for the queued groups, from ListGroups, in the order NZBGet downloads them:
+// highest priority first, then queue position. The rate is capped by the Status download limit,
+// and a scheduled resume time delays the start of the queue. If the rate is 0, unpaused groups
+// are marked Unknown and have no ETA.
+func (e *Estimator) Estimate(status *Status, groups []*Group) *QueueETA {
+	rate := e.Rate()
+	if status.DownloadLimit > 0 && rate > status.DownloadLimit {
+		rate = status.DownloadLimit
+	}
+
+	ordered := make([]*Group, len(groups))
+	copy(ordered, groups)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].MaxPriority > ordered[j].MaxPriority })
+
+	now := time.Now()
+	queuePaused := status.DownloadPaused
+
+	if queuePaused && status.ResumeTime.After(now) {
+		now, queuePaused = status.ResumeTime.Time, false
+	}
+
+	output := &QueueETA{Rate: rate, Items: make([]*ItemETA, len(ordered))}
+	next := now
+
+	for idx, group := range ordered {
+		item := &ItemETA{Group: group, Paused: queuePaused || group.Status == GroupPAUSED}
+		item.Unknown = !item.Paused && rate <= 0
+		output.Items[idx] = item
+		output.Unknown = output.Unknown || item.Unknown
+
+		if item.Paused || item.Unknown {
+			continue
+		}
+
+		remaining := JoinSize(group.RemainingSizeHi, group.RemainingSizeLo) -
+			JoinSize(group.PausedSizeHi, group.PausedSizeLo)
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		item.Start = next
+		next = next.Add(time.Duration(float64(remaining) / float64(rate) * float64(time.Second)))
+		item.Finish = next
+		output.Finish = next
+	}
+
+	if !output.Finish.IsZero() {
+		output.Remaining = time.Until(output.Finish)
+	}
+
+	return output
+}
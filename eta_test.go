@@ -0,0 +1,51 @@
+package nzbget
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	t.Parallel()
+
+	groups := []*Group{
+		{NZBID: 1, Status: GroupQUEUED, RemainingSizeLo: 1000},
+		{NZBID: 2, Status: GroupPAUSED, RemainingSizeLo: 1000},
+		{NZBID: 3, Status: GroupQUEUED, RemainingSizeLo: 2000, MaxPriority: 100},
+	}
+
+	estimator := NewEstimator(0)
+
+	// No rate yet: unpaused groups are unknown, not paused.
+	estimate := estimator.Estimate(&Status{}, groups)
+	if !estimate.Unknown || !estimate.Finish.IsZero() {
+		t.Fatalf("expected an unknown estimate, got: %+v", estimate)
+	}
+
+	for _, item := range estimate.Items {
+		if paused := item.Group.Status == GroupPAUSED; item.Paused != paused || item.Unknown == paused {
+			t.Errorf("group %d: paused %v, unknown %v", item.Group.NZBID, item.Paused, item.Unknown)
+		}
+	}
+
+	estimator.AddSample(&Status{DownloadRate: 1000, AverageDownloadRate: 1000})
+
+	estimate = estimator.Estimate(&Status{}, groups)
+	if estimate.Unknown || estimate.Items[0].Group.NZBID != 3 {
+		t.Fatalf("expected the high priority group first, got: %+v", estimate.Items[0])
+	}
+
+	if took := estimate.Items[0].Finish.Sub(estimate.Items[0].Start); took != 2*time.Second {
+		t.Errorf("group 3 takes %v, want 2s", took)
+	}
+
+	if took := estimate.Finish.Sub(estimate.Items[0].Start); took != 3*time.Second {
+		t.Errorf("queue takes %v, want 3s", took)
+	}
+
+	// A paused queue has no estimate, even with a rate.
+	estimate = estimator.Estimate(&Status{DownloadPaused: true}, groups)
+	if estimate.Unknown || !estimate.Finish.IsZero() || !estimate.Items[0].Paused {
+		t.Errorf("expected a paused estimate, got: %+v", estimate)
+	}
+}
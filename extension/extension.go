@@ -0,0 +1,417 @@
+// Package extension generates the files NZBGet needs to install a Go extension:
+// a manifest.json for modern NZBGet, and the legacy script header comment.
+// Extension options are declared once as a tagged Go struct, which is also used
+// to load the option values NZBGet passes to the running extension.
+//
+// Options are struct fields with these tags:
+//
+//	name:"SendMail"               // option name, default: the field name.
+//	display:"Send Mail"           // display name, default: the option name.
+//	default:"Always"              // default value.
+//	description:"When to send."   // description; use \n to separate paragraphs.
+//	select:"Always,OnFailure"     // allowed values; numbers may use a range like 1-65535.
+//	type:"password"               // optional override: string, number, bool or password.
+//
+// Bool fields are yes/no options. Fields tagged with name:"-" are skipped.
+package extension
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidOptions is returned when Options is not a struct or pointer to a struct.
+var ErrInvalidOptions = errors.New("options must be a struct or pointer to a struct")
+
+// Kind is a kind of NZBGet extension.
+type Kind string
+
+// Kinds go here.
+const (
+	KindPostProcessing Kind = "POST-PROCESSING"
+	KindQueue          Kind = "QUEUE"
+	KindScan           Kind = "SCAN"
+	KindScheduler      Kind = "SCHEDULER"
+)
+
+// Extension describes an NZBGet extension.
+type Extension struct {
+	Main         string // executable file name, like main or main.exe.
+	Name         string
+	DisplayName  string
+	Version      string
+	Author       string
+	License      string
+	Homepage     string
+	About        string // one line summary.
+	Kinds        []Kind
+	QueueEvents  string // queue events to run for, like NZB_ADDED, NZB_DOWNLOADED.
+	TaskTime     string // scheduler run times, like *;*:00.
+	Description  []string
+	Requirements []string
+	Commands     []*Command
+	Options      interface{} // tagged struct describing the options.
+}
+
+// Command is a button in the NZBGet settings that runs the extension.
+type Command struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Action      string   `json:"action"` // button text.
+	Description []string `json:"description"`
+}
+
+// Manifest is the manifest.json content.
+type Manifest struct {
+	Main         string        `json:"main"`
+	Name         string        `json:"name"`
+	HomePage     string        `json:"homepage"`
+	Kind         string        `json:"kind"`
+	DisplayName  string        `json:"displayName"`
+	Version      string        `json:"version"`
+	Author       string        `json:"author"`
+	License      string        `json:"license"`
+	About        string        `json:"about"`
+	QueueEvents  string        `json:"queueEvents"`
+	TaskTime     string        `json:"taskTime"`
+	Description  []string      `json:"description"`
+	Requirements []string      `json:"requirements"`
+	Options      []*Option     `json:"options"`
+	Commands     []*Command    `json:"commands"`
+	Sections     []interface{} `json:"sections"`
+}
+
+// Option is one extension option, generated from a struct field.
+type Option struct {
+	Name        string        `json:"name"`
+	DisplayName string        `json:"displayName"`
+	Value       interface{}   `json:"value"`
+	Description []string      `json:"description"`
+	Select      []interface{} `json:"select"`
+	Type        string        `json:"-"`
+	field       int
+	fallback    string
+}
+
+// Manifest returns the manifest for the extension.
+func (e *Extension) Manifest() (*Manifest, error) {
+	options, err := ParseOptions(e.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := make([]string, len(e.Kinds))
+	for idx, kind := range e.Kinds {
+		kinds[idx] = string(kind)
+	}
+
+	displayName := e.DisplayName
+	if displayName == "" {
+		displayName = e.Name
+	}
+
+	return &Manifest{
+		Main:         e.Main,
+		Name:         e.Name,
+		HomePage:     e.Homepage,
+		Kind:         strings.Join(kinds, "/"),
+		DisplayName:  displayName,
+		Version:      e.Version,
+		Author:       e.Author,
+		License:      e.License,
+		About:        e.About,
+		QueueEvents:  e.QueueEvents,
+		TaskTime:     e.TaskTime,
+		Description:  nonNil(e.Description),
+		Requirements: nonNil(e.Requirements),
+		Options:      options,
+		Commands:     commands(e.Commands),
+		Sections:     []interface{}{},
+	}, nil
+}
+
+// WriteManifest writes the manifest.json content to w.
+func (e *Extension) WriteManifest(w io.Writer) error {
+	manifest, err := e.Manifest()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	return nil
+}
+
+// Header returns the legacy script header comment, as NZBGet before v23 reads it.
+// The options section matches what ConfigTemplates reports for the script.
+func (e *Extension) Header() (string, error) {
+	options, err := ParseOptions(e.Options)
+	if err != nil {
+		return "", err
+	}
+
+	kinds := make([]string, len(e.Kinds))
+	for idx, kind := range e.Kinds {
+		kinds[idx] = string(kind)
+	}
+
+	var buf bytes.Buffer
+
+	banner := fmt.Sprintf("### NZBGET %s SCRIPT", strings.Join(kinds, "/"))
+	rule := strings.Repeat("#", 78) //nolint:gomnd
+
+	fmt.Fprintf(&buf, "%s\n%-75s###\n\n", rule, banner)
+	fmt.Fprintf(&buf, "# %s\n", e.About)
+
+	for _, line := range e.Description {
+		fmt.Fprintf(&buf, "#\n# %s\n", line)
+	}
+
+	for _, line := range e.Requirements {
+		fmt.Fprintf(&buf, "#\n# NOTE: %s\n", line)
+	}
+
+	if e.QueueEvents != "" {
+		fmt.Fprintf(&buf, "\n# QUEUE EVENTS: %s\n", e.QueueEvents)
+	}
+
+	if e.TaskTime != "" {
+		fmt.Fprintf(&buf, "\n# TASK TIME: %s\n", e.TaskTime)
+	}
+
+	fmt.Fprintf(&buf, "\n%s\n%-75s###\n", rule, "### OPTIONS")
+
+	for _, option := range options {
+		fmt.Fprintf(&buf, "\n%s#%s=%v\n", option.comment(), option.Name, option.Value)
+	}
+
+	for _, command := range e.Commands {
+		fmt.Fprintf(&buf, "\n%s#%s@%s\n", comment(command.Description, ""), command.Name, command.Action)
+	}
+
+	fmt.Fprintf(&buf, "\n%-75s###\n%s\n", banner, rule)
+
+	return buf.String(), nil
+}
+
+// comment returns the description lines of an option, with its allowed values after the first line.
+func (o *Option) comment() string {
+	selects := make([]string, len(o.Select))
+	for idx, val := range o.Select {
+		selects[idx] = fmt.Sprint(val)
+	}
+
+	suffix := ""
+
+	switch {
+	case len(selects) == 0:
+	case o.Type == typeNumber && len(selects) == 2: //nolint:gomnd
+		suffix = fmt.Sprintf(" (%s-%s)", selects[0], selects[1])
+	default:
+		suffix = fmt.Sprintf(" (%s)", strings.Join(selects, ", "))
+	}
+
+	return comment(o.Description, suffix)
+}
+
+// comment turns description paragraphs into comment lines, adding suffix to the first sentence.
+func comment(description []string, suffix string) string {
+	var buf bytes.Buffer
+
+	for idx, line := range description {
+		if idx == 0 && suffix != "" {
+			line = strings.TrimSuffix(line, ".") + suffix + "."
+		}
+
+		if idx > 0 {
+			buf.WriteString("#\n")
+		}
+
+		fmt.Fprintf(&buf, "# %s\n", line)
+	}
+
+	if len(description) == 0 && suffix != "" {
+		fmt.Fprintf(&buf, "#%s.\n", suffix)
+	}
+
+	return buf.String()
+}
+
+// commands returns a copy of the commands with display names filled in.
+func commands(list []*Command) []*Command {
+	output := make([]*Command, len(list))
+
+	for idx, command := range list {
+		cmd := *command
+		if cmd.DisplayName == "" {
+			cmd.DisplayName = cmd.Name
+		}
+
+		cmd.Description = nonNil(cmd.Description)
+		output[idx] = &cmd
+	}
+
+	return output
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+
+	return list
+}
+
+// Option types.
+const (
+	typeString   = "string"
+	typeNumber   = "number"
+	typeBool     = "bool"
+	typePassword = "password"
+)
+
+// ParseOptions returns the options described by a tagged struct.
+func ParseOptions(options interface{}) ([]*Option, error) {
+	if options == nil {
+		return []*Option{}, nil
+	}
+
+	typ := reflect.TypeOf(options)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return nil, ErrInvalidOptions
+	}
+
+	output := []*Option{}
+
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+		if !field.IsExported() || field.Tag.Get("name") == "-" {
+			continue
+		}
+
+		option, err := parseOption(field)
+		if err != nil {
+			return nil, err
+		}
+
+		option.field = idx
+		output = append(output, option)
+	}
+
+	return output, nil
+}
+
+func parseOption(field reflect.StructField) (*Option, error) {
+	option := &Option{
+		Name:        field.Tag.Get("name"),
+		DisplayName: field.Tag.Get("display"),
+		Type:        field.Tag.Get("type"),
+		Description: []string{},
+		Select:      []interface{}{},
+	}
+
+	if option.Name == "" {
+		option.Name = field.Name
+	}
+
+	if option.DisplayName == "" {
+		option.DisplayName = option.Name
+	}
+
+	if desc := field.Tag.Get("description"); desc != "" {
+		option.Description = strings.Split(desc, "\n")
+	}
+
+	if option.Type == "" {
+		option.Type = kindType(field.Type.Kind())
+	}
+
+	value := field.Tag.Get("default")
+
+	switch option.Type {
+	case typeBool:
+		if value == "" {
+			value = "no"
+		}
+
+		option.Value, option.Select = value, []interface{}{"yes", "no"}
+	case typeNumber:
+		if value == "" {
+			value = "0"
+		}
+
+		number, err := strconv.ParseFloat(value, 64) //nolint:gomnd
+		if err != nil {
+			return nil, fmt.Errorf("option %s default: %w", option.Name, err)
+		}
+
+		option.Value = number
+
+		for _, val := range splitSelect(field.Tag.Get("select"), true) {
+			number, err := strconv.ParseFloat(val, 64) //nolint:gomnd
+			if err != nil {
+				return nil, fmt.Errorf("option %s select: %w", option.Name, err)
+			}
+
+			option.Select = append(option.Select, number)
+		}
+	default:
+		option.Value = value
+
+		for _, val := range splitSelect(field.Tag.Get("select"), false) {
+			option.Select = append(option.Select, val)
+		}
+	}
+
+	option.fallback = value
+
+	return option, nil
+}
+
+// splitSelect splits select values on commas, and number ranges like 1-65535 on the dash.
+func splitSelect(tag string, number bool) []string {
+	if tag == "" {
+		return nil
+	}
+
+	if number && !strings.Contains(tag, ",") {
+		if low, high, ok := strings.Cut(tag[1:], "-"); ok {
+			return []string{tag[:1] + low, high}
+		}
+	}
+
+	values := strings.Split(tag, ",")
+	for idx := range values {
+		values[idx] = strings.TrimSpace(values[idx])
+	}
+
+	return values
+}
+
+func kindType(kind reflect.Kind) string {
+	switch kind { //nolint:exhaustive
+	case reflect.Bool:
+		return typeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return typeNumber
+	default:
+		return typeString
+	}
+}
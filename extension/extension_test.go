@@ -0,0 +1,116 @@
+package extension
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata") //nolint:gochecknoglobals
+
+// testExtension returns an extension with every kind of option, for the golden tests.
+func testExtension() *Extension {
+	return &Extension{
+		Main:         "main",
+		Name:         "Notify",
+		DisplayName:  "Notify Me",
+		Version:      "1.2.0",
+		Author:       "Gopher",
+		License:      "MIT",
+		Homepage:     "https://golift.io/nzbget",
+		About:        "Sends notifications when downloads finish.",
+		Kinds:        []Kind{KindPostProcessing, KindQueue},
+		QueueEvents:  "NZB_ADDED, NZB_DOWNLOADED",
+		Description:  []string{"Posts a message for every finished download.", "Failed downloads are included."},
+		Requirements: []string{"This extension needs network access."},
+		Commands: []*Command{{
+			Name:        "Test",
+			Action:      "Send Test",
+			Description: []string{"Sends a test message."},
+		}},
+		Options: &struct {
+			Enabled  bool   `name:"Enabled" default:"yes" description:"Send messages."`
+			When     string `name:"When" display:"Send When" default:"Always" select:"Always,OnFailure" description:"When to send.\nOnFailure skips successful downloads."` //nolint:lll
+			Port     int    `name:"Port" default:"8080" select:"1-65535" description:"Port to connect to."`
+			Token    string `name:"Token" type:"password" description:"API token."`
+			Internal string `name:"-"`
+		}{},
+	}
+}
+
+// golden compares got with a file in testdata, or rewrites the file with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	t.Parallel()
+
+	manifest, err := testExtension().Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "manifest.json", append(got, '\n'))
+}
+
+func TestWriteManifest(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := testExtension().WriteManifest(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "manifest.json", buf.Bytes())
+}
+
+func TestHeader(t *testing.T) {
+	t.Parallel()
+
+	header, err := testExtension().Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "header.txt", []byte(header))
+}
+
+func TestInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	ext := &Extension{Options: "not a struct"}
+
+	if _, err := ext.Manifest(); err != ErrInvalidOptions { //nolint:errorlint
+		t.Errorf("Manifest: got %v, want ErrInvalidOptions", err)
+	}
+
+	if _, err := ext.Header(); err != ErrInvalidOptions { //nolint:errorlint
+		t.Errorf("Header: got %v, want ErrInvalidOptions", err)
+	}
+}
@@ -0,0 +1,75 @@
+package extension
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"golift.io/nzbget/script"
+)
+
+// ErrNotPointer is returned when Load is not given a pointer to a struct.
+var ErrNotPointer = errors.New("options must be a pointer to a struct")
+
+// Load fills a tagged options struct from the NZBPO_ variables NZBGet passes to the extension.
+// Options missing from the environment get their default value, and so do number
+// options NZBGet passes as empty strings.
+func Load(env script.Env, options interface{}) error {
+	value := reflect.ValueOf(options)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return ErrNotPointer
+	}
+
+	parsed, err := ParseOptions(options)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range parsed {
+		field := value.Elem().Field(option.field)
+
+		val, ok := env["NZBPO_"+option.Name]
+		if !ok || (val == "" && kindType(field.Kind()) == typeNumber) {
+			val = option.fallback
+		}
+
+		if err := setField(field, val); err != nil {
+			return fmt.Errorf("option %s: %w", option.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(field reflect.Value, val string) error {
+	switch field.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		field.SetBool(script.ParseBool(val))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(val, 10, field.Type().Bits()) //nolint:gomnd
+		if err != nil {
+			return fmt.Errorf("parsing number: %w", err)
+		}
+
+		field.SetInt(number)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(val, 10, field.Type().Bits()) //nolint:gomnd
+		if err != nil {
+			return fmt.Errorf("parsing number: %w", err)
+		}
+
+		field.SetUint(number)
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(val, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("parsing number: %w", err)
+		}
+
+		field.SetFloat(number)
+	case reflect.String:
+		field.SetString(val)
+	}
+
+	return nil
+}
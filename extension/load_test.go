@@ -0,0 +1,33 @@
+package extension
+
+import (
+	"testing"
+
+	"golift.io/nzbget/script"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	var options struct {
+		Enabled bool    `name:"Enabled" default:"yes"`
+		Verbose bool    `name:"Verbose"`
+		Port    int     `name:"Port" default:"8080"`
+		Ratio   float64 `name:"Ratio" default:"1.5"`
+		Name    string  `name:"Name" default:"nzbget"`
+	}
+
+	env := script.Env{"NZBPO_Verbose": "On", "NZBPO_Port": "", "NZBPO_Name": ""}
+	if err := Load(env, &options); err != nil {
+		t.Fatal(err)
+	}
+
+	// Missing options and empty numbers get their defaults. Empty strings stay empty.
+	if !options.Enabled || !options.Verbose || options.Port != 8080 || options.Ratio != 1.5 || options.Name != "" {
+		t.Fatalf("unexpected options: %+v", options)
+	}
+
+	if err := Load(script.Env{"NZBPO_Port": "eighty"}, &options); err == nil {
+		t.Fatal("expected an error for an invalid number")
+	}
+}
@@ -0,0 +1,285 @@
+// Package failhandler watches NZBGet's history for failed downloads and handles them
+// according to rules: retry, redownload, mark bad or delete. A ledger caps the attempts
+// per duplicate key, and every action is written to an audit trail.
+package failhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/internal/poll"
+	"golift.io/nzbget/internal/statefile"
+)
+
+// DefaultInterval is how often the Handler checks history.
+const DefaultInterval = time.Minute
+
+// DefaultForgetAfter is how long the ledger keeps the attempts for a duplicate key
+// after the last attempt.
+const DefaultForgetAfter = 30 * 24 * time.Hour
+
+// Client is the part of *nzbget.NZBGet the Handler uses.
+type Client interface {
+	HistoryContext(ctx context.Context, hidden bool) ([]*nzbget.History, error)
+	EditQueueContext(ctx context.Context, command, parameter string, ids []int64) (bool, error)
+}
+
+// Config is the input data needed to return a Handler.
+type Config struct {
+	Rules       []*Rule       // first matching rule wins.
+	StateFile   string        // optional, persists the ledger between restarts.
+	Interval    time.Duration // default: DefaultInterval
+	ForgetAfter time.Duration // forget a duplicate key's attempts this long after the last one, default: DefaultForgetAfter
+	Backfill    bool          // handle history that existed before the first check.
+	Audit       io.Writer     // optional, receives a JSON line for every action.
+	Logger      nzbget.Logger // optional, default: log.Default()
+}
+
+// AuditEntry records one action taken by the Handler.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	NZBID    int64     `json:"nzbId"`
+	Name     string    `json:"name"`
+	Status   string    `json:"status"`
+	DupeKey  string    `json:"dupeKey"`
+	Rule     string    `json:"rule"`
+	Action   Action    `json:"action"`
+	Attempts int       `json:"attempts"` // attempts made for the duplicate key, including this one if it succeeded.
+	Error    string    `json:"error,omitempty"`
+}
+
+// Ledger is the Handler state saved to the StateFile.
+type Ledger struct {
+	// HistoryCursor tracks the newest handled items.
+	nzbget.HistoryCursor
+	// Attempts counts the successful Action attempts per duplicate key.
+	Attempts map[string]int `json:"attempts"`
+	// LastAttempt is the time of the last attempt per duplicate key. Keys are
+	// removed from the ledger ForgetAfter their last attempt.
+	LastAttempt map[string]time.Time `json:"lastAttempt"`
+	// Primed is true once the first check finished. Later checks handle every new
+	// item, even if the first check found an empty history.
+	Primed bool `json:"primed"`
+}
+
+// Handler applies rules to failed history items.
+type Handler struct {
+	client Client
+	config *Config
+	mu     sync.Mutex
+	ledger Ledger
+}
+
+// New returns a Handler, and loads its ledger from the state file if it exists.
+func New(client Client, config *Config) (*Handler, error) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+
+	if config.ForgetAfter <= 0 {
+		config.ForgetAfter = DefaultForgetAfter
+	}
+
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	handler := &Handler{client: client, config: config, ledger: Ledger{
+		Attempts:    make(map[string]int),
+		LastAttempt: make(map[string]time.Time),
+	}}
+
+	return handler, handler.load()
+}
+
+// Run checks history immediately, and then every Interval until the context ends.
+// Errors are logged, and do not stop the handler.
+func (h *Handler) Run(ctx context.Context) {
+	poll.Run(ctx, h.config.Interval, h.config.Logger, "failed download handler", func(ctx context.Context) error {
+		_, err := h.Check(ctx)
+		return err
+	})
+}
+
+// Check handles history items added since the last check, and returns the actions taken.
+// Items with a SUCCESS status are never handled. The first check only records the newest
+// item, unless Backfill is enabled. Attempts older than ForgetAfter are removed from the ledger.
+func (h *Handler) Check(ctx context.Context) ([]*AuditEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	history, err := h.client.HistoryContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("getting history: %w", err)
+	}
+
+	backfill := h.config.Backfill || h.ledger.Primed
+	entries := []*AuditEntry{}
+
+	for _, item := range h.ledger.Advance(history) {
+		if !backfill || strings.HasPrefix(item.Status, "SUCCESS") {
+			continue
+		}
+
+		if entry := h.handle(ctx, item); entry != nil {
+			entries = append(entries, entry)
+			h.audit(entry)
+		}
+	}
+
+	h.ledger.Primed = true
+	h.forget(time.Now().Add(-h.config.ForgetAfter))
+
+	return entries, h.save()
+}
+
+// handle finds the first matching rule for an item and applies its action.
+func (h *Handler) handle(ctx context.Context, item *nzbget.History) *AuditEntry {
+	for _, rule := range h.config.Rules {
+		if !rule.Match(item) {
+			continue
+		}
+
+		key := item.DupeKey
+		if key == "" {
+			key = item.Name
+		}
+
+		action := rule.Action
+		counted := rule.MaxAttempts <= 0 || h.ledger.Attempts[key] < rule.MaxAttempts
+
+		if !counted {
+			action = rule.Fallback
+		}
+
+		if action == ActionNone {
+			return nil
+		}
+
+		entry := &AuditEntry{
+			Time:     time.Now(),
+			NZBID:    item.NZBID,
+			Name:     item.Name,
+			Status:   item.Status,
+			DupeKey:  item.DupeKey,
+			Rule:     rule.Name,
+			Action:   action,
+			Attempts: h.ledger.Attempts[key],
+		}
+
+		// Only count an attempt NZBGet accepted, so a failed edit does not use one up.
+		if _, err := h.client.EditQueueContext(ctx, string(action), "", []int64{item.NZBID}); err != nil {
+			entry.Error = err.Error()
+		} else if counted {
+			h.ledger.Attempts[key]++
+			h.ledger.LastAttempt[key] = entry.Time
+			entry.Attempts = h.ledger.Attempts[key]
+		}
+
+		return entry
+	}
+
+	return nil
+}
+
+// forget removes the attempts for duplicate keys last attempted before cutoff.
+func (h *Handler) forget(cutoff time.Time) {
+	for key := range h.ledger.Attempts {
+		if h.ledger.LastAttempt[key].Before(cutoff) {
+			delete(h.ledger.Attempts, key)
+			delete(h.ledger.LastAttempt, key)
+		}
+	}
+}
+
+// audit logs an action and writes it to the audit trail.
+func (h *Handler) audit(entry *AuditEntry) {
+	if entry.Error != "" {
+		h.config.Logger.Printf("[ERROR] failed download handler: %s %s (%d): %s",
+			entry.Action, entry.Name, entry.NZBID, entry.Error)
+	} else {
+		h.config.Logger.Printf("failed download handler: %s %s (%d), rule: %s, attempts: %d",
+			entry.Action, entry.Name, entry.NZBID, entry.Rule, entry.Attempts)
+	}
+
+	if h.config.Audit == nil {
+		return
+	}
+
+	if err := json.NewEncoder(h.config.Audit).Encode(entry); err != nil {
+		h.config.Logger.Printf("[ERROR] failed download handler: writing audit trail: %v", err)
+	}
+}
+
+// Ledger returns a copy of the handler's ledger.
+func (h *Handler) Ledger() Ledger {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ledger := Ledger{
+		HistoryCursor: nzbget.HistoryCursor{Since: h.ledger.Since, IDs: append([]int64{}, h.ledger.IDs...)},
+		Attempts:      make(map[string]int, len(h.ledger.Attempts)),
+		LastAttempt:   make(map[string]time.Time, len(h.ledger.LastAttempt)),
+		Primed:        h.ledger.Primed,
+	}
+
+	for key, val := range h.ledger.Attempts {
+		ledger.Attempts[key] = val
+	}
+
+	for key, val := range h.ledger.LastAttempt {
+		ledger.LastAttempt[key] = val
+	}
+
+	return ledger
+}
+
+// load reads the state file, if one is configured and exists.
+func (h *Handler) load() error {
+	if h.config.StateFile == "" {
+		return nil
+	}
+
+	if err := statefile.Load(h.config.StateFile, &h.ledger); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if h.ledger.Attempts == nil {
+		h.ledger.Attempts = make(map[string]int)
+	}
+
+	if h.ledger.LastAttempt == nil {
+		h.ledger.LastAttempt = make(map[string]time.Time)
+	}
+
+	// State files written before LastAttempt existed have no attempt times.
+	// Start the clock on those keys now, so they are not forgotten right away.
+	for key := range h.ledger.Attempts {
+		if _, ok := h.ledger.LastAttempt[key]; !ok {
+			h.ledger.LastAttempt[key] = time.Now()
+		}
+	}
+
+	// State files written before Primed existed only have a cursor after a check.
+	if !h.ledger.IsZero() {
+		h.ledger.Primed = true
+	}
+
+	return nil
+}
+
+// save writes the ledger to the state file, if one is configured.
+func (h *Handler) save() error {
+	if h.config.StateFile == "" {
+		return nil
+	}
+
+	return statefile.Save(h.config.StateFile, h.ledger) //nolint:wrapcheck
+}
@@ -0,0 +1,212 @@
+package failhandler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// fakeClient returns history, and records the items edited. Edits fail while err is set.
+type fakeClient struct {
+	history []*nzbget.History
+	edited  []int64
+	err     error
+}
+
+func (f *fakeClient) HistoryContext(context.Context, bool) ([]*nzbget.History, error) {
+	return f.history, nil
+}
+
+func (f *fakeClient) EditQueueContext(_ context.Context, _, _ string, ids []int64) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+
+	f.edited = append(f.edited, ids...)
+
+	return true, nil
+}
+
+func TestCheckAfterEmptyHistory(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{}
+	config := &Config{
+		Rules:     []*Rule{{Name: "retry", Action: ActionRetry}},
+		StateFile: filepath.Join(t.TempDir(), "state.json"),
+		Logger:    log.New(io.Discard, "", 0),
+	}
+
+	handler, err := New(client, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first check finds an empty history, which still primes the handler.
+	if _, err := handler.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if !handler.Ledger().Primed {
+		t.Fatal("handler is not primed after the first check")
+	}
+
+	// A restart loads the primed ledger, and the next failure is handled.
+	if handler, err = New(client, config); err != nil {
+		t.Fatal(err)
+	}
+
+	client.history = []*nzbget.History{
+		{NZBID: 2, Status: "FAILURE/PAR", HistoryTime: nzbget.Time{Time: time.Unix(1700000100, 0)}},
+		{NZBID: 1, Status: "SUCCESS/ALL", HistoryTime: nzbget.Time{Time: time.Unix(1700000000, 0)}},
+	}
+
+	entries, err := handler.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || len(client.edited) != 1 || client.edited[0] != 2 {
+		t.Fatalf("expected item 2 retried, got %d entries, edited: %v", len(entries), client.edited)
+	}
+}
+
+func TestCheckSkipsExistingHistory(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{history: []*nzbget.History{
+		{NZBID: 1, Status: "FAILURE/PAR", HistoryTime: nzbget.Time{Time: time.Unix(1700000000, 0)}},
+	}}
+
+	handler, err := New(client, &Config{Rules: []*Rule{{Action: ActionRetry}}, Logger: log.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, err := handler.Check(context.Background()); err != nil || len(entries) != 0 {
+		t.Fatalf("first check without Backfill: got %d entries, %v", len(entries), err)
+	}
+}
+
+func TestAttempts(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{}
+	rule := &Rule{Name: "retry", Action: ActionRetry, MaxAttempts: 2, Fallback: ActionMarkBad}
+	config := &Config{Rules: []*Rule{rule}, Backfill: true, Logger: log.New(io.Discard, "", 0)}
+
+	handler, err := New(client, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check adds a new failure for the same duplicate key, and returns the action taken.
+	check := func(id int64) *AuditEntry {
+		t.Helper()
+
+		client.history = []*nzbget.History{{
+			NZBID: id, Name: "Show.S01E01", DupeKey: "show-s01e01", Status: "FAILURE/PAR",
+			HistoryTime: nzbget.Time{Time: time.Unix(1700000000+id, 0)},
+		}}
+
+		entries, err := handler.Check(context.Background())
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("item %d: got %d entries, %v", id, len(entries), err)
+		}
+
+		return entries[0]
+	}
+
+	client.err = errors.New("connection refused")
+	if entry := check(1); entry.Action != ActionRetry || entry.Attempts != 0 || entry.Error == "" {
+		t.Errorf("failed edit: got %+v, want an uncounted retry", entry)
+	}
+
+	client.err = nil
+	if entry := check(2); entry.Action != ActionRetry || entry.Attempts != 1 {
+		t.Errorf("first attempt: got %+v", entry)
+	}
+
+	if entry := check(3); entry.Action != ActionRetry || entry.Attempts != 2 {
+		t.Errorf("second attempt: got %+v", entry)
+	}
+
+	if entry := check(4); entry.Action != ActionMarkBad || entry.Attempts != 2 {
+		t.Errorf("after max attempts: got %+v, want the fallback", entry)
+	}
+
+	if attempts := handler.Ledger().Attempts["show-s01e01"]; attempts != 2 {
+		t.Errorf("ledger has %d attempts, want 2", attempts)
+	}
+}
+
+func TestForget(t *testing.T) {
+	t.Parallel()
+
+	handler, err := New(&fakeClient{}, &Config{ForgetAfter: time.Hour, Logger: log.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.ledger.Attempts = map[string]int{"old": 3, "new": 1}
+	handler.ledger.LastAttempt = map[string]time.Time{"old": time.Now().Add(-2 * time.Hour), "new": time.Now()}
+
+	if _, err := handler.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ledger := handler.Ledger()
+	if _, ok := ledger.Attempts["old"]; ok || ledger.Attempts["new"] != 1 {
+		t.Errorf("expected only the old key forgotten, got %v", ledger.Attempts)
+	}
+
+	if _, ok := ledger.LastAttempt["old"]; ok {
+		t.Errorf("the old key's attempt time was kept: %v", ledger.LastAttempt)
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	t.Parallel()
+
+	item := &nzbget.History{
+		Category:     "tv",
+		Status:       "FAILURE/PAR",
+		ParStatus:    nzbget.ParFAILURE,
+		UnpackStatus: nzbget.UnpackNONE,
+		DeleteStatus: nzbget.DeleteNONE,
+		Health:       800,
+	}
+
+	tests := []struct {
+		name  string
+		rule  Rule
+		match bool
+	}{
+		{name: "empty", rule: Rule{}, match: true},
+		{name: "category", rule: Rule{Categories: []string{"movies", "tv"}}, match: true},
+		{name: "other category", rule: Rule{Categories: []string{"movies"}}, match: false},
+		{name: "status family", rule: Rule{Statuses: []string{"FAILURE"}}, match: true},
+		{name: "exact status", rule: Rule{Statuses: []string{"FAILURE/PAR"}}, match: true},
+		{name: "other status", rule: Rule{Statuses: []string{"DELETED", "FAILURE/UNPACK"}}, match: false},
+		{name: "par status", rule: Rule{ParStatuses: []nzbget.ParStatus{nzbget.ParFAILURE}}, match: true},
+		{name: "unpack status", rule: Rule{UnpackStatus: []nzbget.UnpackStatus{nzbget.UnpackFAILURE}}, match: false},
+		{name: "delete status", rule: Rule{DeleteStatus: []nzbget.DeleteStatus{nzbget.DeleteNONE}}, match: true},
+		{name: "health at max", rule: Rule{MaxHealth: 800}, match: true},
+		{name: "health above max", rule: Rule{MaxHealth: 799}, match: false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if match := test.rule.Match(item); match != test.match {
+				t.Errorf("got %v, want %v", match, test.match)
+			}
+		})
+	}
+}
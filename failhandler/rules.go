@@ -0,0 +1,50 @@
+package failhandler
+
+import (
+	"golift.io/nzbget"
+	"golift.io/nzbget/internal/list"
+)
+
+// Action is an EditQueue history command the Handler can issue.
+type Action string
+
+// Actions go here.
+const (
+	ActionNone       Action = ""                   // do nothing.
+	ActionRetry      Action = "HistoryRetryFailed" // download the remaining failed articles.
+	ActionRedownload Action = "HistoryRedownload"  // download the whole item again.
+	ActionMarkBad    Action = "HistoryMarkBad"     // mark bad, so duplicate handling finds another release.
+	ActionDelete     Action = "HistoryDelete"      // hide the item in history.
+)
+
+// Rule matches failed history items and picks an Action for them.
+// Every non-empty field must match. Fields with lists match if any one value matches.
+//
+//nolint:lll
+type Rule struct {
+	Name         string                `json:"name"          toml:"name"           xml:"name"           yaml:"name"`
+	Categories   []string              `json:"categories"    toml:"categories"     xml:"category"       yaml:"categories"`
+	Statuses     []string              `json:"statuses"      toml:"statuses"       xml:"status"         yaml:"statuses"` // like FAILURE/PAR, or a family like DELETED.
+	ParStatuses  []nzbget.ParStatus    `json:"parStatuses"   toml:"par_statuses"   xml:"par_status"     yaml:"parStatuses"`
+	UnpackStatus []nzbget.UnpackStatus `json:"unpackStatus"  toml:"unpack_status"  xml:"unpack_status"  yaml:"unpackStatus"`
+	DeleteStatus []nzbget.DeleteStatus `json:"deleteStatus"  toml:"delete_status"  xml:"delete_status"  yaml:"deleteStatus"`
+	MaxHealth    int64                 `json:"maxHealth"     toml:"max_health"     xml:"max_health"     yaml:"maxHealth"` // per mille (0-1000), match Health at or below this. 0 skips the check.
+	Action       Action                `json:"action"        toml:"action"         xml:"action"         yaml:"action"`
+	MaxAttempts  int                   `json:"maxAttempts"   toml:"max_attempts"   xml:"max_attempts"   yaml:"maxAttempts"` // per duplicate key, 0 is unlimited.
+	Fallback     Action                `json:"fallback"      toml:"fallback"       xml:"fallback"       yaml:"fallback"`    // used once MaxAttempts is reached.
+}
+
+// Match returns true if the history item matches every field in the rule.
+func (r *Rule) Match(item *nzbget.History) bool {
+	switch {
+	case len(r.Categories) > 0 && !list.Contains(r.Categories, item.Category),
+		len(r.Statuses) > 0 && !list.ContainsFamily(r.Statuses, item.Status),
+		len(r.ParStatuses) > 0 && !list.Contains(r.ParStatuses, item.ParStatus),
+		len(r.UnpackStatus) > 0 && !list.Contains(r.UnpackStatus, item.UnpackStatus),
+		len(r.DeleteStatus) > 0 && !list.Contains(r.DeleteStatus, item.DeleteStatus),
+		r.MaxHealth > 0 && item.Health > r.MaxHealth:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,39 @@
+// Package list has slice helpers shared by the packages in this module.
+package list
+
+import "strings"
+
+// Contains returns true if value is in list.
+func Contains[T comparable](list []T, value T) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContainsFold returns true if value is in list, ignoring case.
+func ContainsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContainsFamily returns true if value is in list, or in a family from list.
+// Families are the part of a value before a slash, so "FAILURE" contains "FAILURE/PAR".
+// NZBGet history statuses are written this way.
+func ContainsFamily(list []string, value string) bool {
+	for _, item := range list {
+		if value == item || strings.HasPrefix(value, item+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,34 @@
+package list
+
+import "testing"
+
+func TestContainsFamily(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value string
+		list  []string
+		want  bool
+	}{
+		{value: "FAILURE/PAR", list: []string{"FAILURE"}, want: true},
+		{value: "FAILURE/PAR", list: []string{"SUCCESS", "FAILURE/PAR"}, want: true},
+		{value: "FAILURE", list: []string{"FAILURE"}, want: true},
+		{value: "FAILURE/PAR", list: []string{"FAIL"}, want: false},
+		{value: "FAILURE", list: []string{"FAILURE/PAR"}, want: false},
+		{value: "FAILURE/PAR", list: nil, want: false},
+	}
+
+	for _, test := range tests {
+		if got := ContainsFamily(test.list, test.value); got != test.want {
+			t.Errorf("ContainsFamily(%q, %q): got %v, want %v", test.list, test.value, got, test.want)
+		}
+	}
+}
+
+func TestContainsFold(t *testing.T) {
+	t.Parallel()
+
+	if !ContainsFold([]string{"Movies", "tv"}, "TV") || ContainsFold([]string{"tv"}, "tv2") || ContainsFold(nil, "") {
+		t.Error("ContainsFold must match whole values, ignoring case")
+	}
+}
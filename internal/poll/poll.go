@@ -0,0 +1,31 @@
+// Package poll runs the polling loop behind the Run methods of the packages in this module.
+package poll
+
+import (
+	"context"
+	"time"
+)
+
+// Logger is the logger polling errors are written to. nzbget.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Run calls check immediately, and then every interval until the context ends.
+// Errors are logged with the name prefixed, and do not stop polling.
+func Run(ctx context.Context, interval time.Duration, logger Logger, name string, check func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := check(ctx); err != nil {
+			logger.Printf("[ERROR] %s: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
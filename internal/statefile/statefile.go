@@ -0,0 +1,45 @@
+// Package statefile reads and writes the JSON state files kept by the packages in this module.
+package statefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Load decodes the JSON state file at path into state. A missing file is not an error.
+func Load(path string, state interface{}) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("parsing state file: %w", err)
+	}
+
+	return nil
+}
+
+// Save writes state to path as JSON. It writes a temporary file and renames it over
+// path, so a crash never leaves a partial state file behind.
+func Save(path string, state interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil { //nolint:gomnd
+		return fmt.Errorf("writing state file: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+
+	return nil
+}
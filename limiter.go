@@ -0,0 +1,91 @@
+package nzbget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// limiter spaces requests out to a maximum rate, and caps concurrent requests.
+// It is shared by every goroutine using the same NZBGet client.
+type limiter struct {
+	interval time.Duration // 0 disables rate limiting.
+	slots    chan struct{} // nil disables the concurrency cap.
+	mu       sync.Mutex
+	next     time.Time // the time the next request may start.
+}
+
+func newLimiter(rate float64, inFlight int) *limiter {
+	limit := &limiter{}
+
+	if rate > 0 {
+		limit.interval = time.Duration(float64(time.Second) / rate)
+	}
+
+	if inFlight > 0 {
+		limit.slots = make(chan struct{}, inFlight)
+	}
+
+	return limit
+}
+
+// middleware waits for an in-flight slot, then for the rate limit, before calling next.
+func (l *limiter) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(ctx context.Context, call *Call) error {
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+				defer func() { <-l.slots }()
+			case <-ctx.Done():
+				return fmt.Errorf("waiting for request slot: %w", ctx.Err())
+			}
+		}
+
+		if err := l.wait(ctx); err != nil {
+			return fmt.Errorf("waiting for rate limit: %w", err)
+		}
+
+		return next(ctx, call)
+	}
+}
+
+// wait reserves the next request time and sleeps until it arrives.
+// The reservation is returned if the context ends first and nobody reserved after it.
+func (l *limiter) wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	start := l.next
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		if l.next.Equal(start.Add(l.interval)) {
+			l.next = start
+		}
+		l.mu.Unlock()
+
+		return ctx.Err() //nolint:wrapcheck // wrapped by caller.
+	}
+}
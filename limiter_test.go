@@ -0,0 +1,122 @@
+package nzbget
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterInFlight(t *testing.T) {
+	t.Parallel()
+
+	var running, most int32
+
+	rt := newLimiter(0, 2).middleware(func(ctx context.Context, call *Call) error {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			old := atomic.LoadInt32(&most)
+			if now <= old || atomic.CompareAndSwapInt32(&most, old, now) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		return nil
+	})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := rt(context.Background(), &Call{Method: "status"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if most != 2 {
+		t.Errorf("got %d requests in flight, want 2", most)
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	rt := newLimiter(100, 0).middleware(func(ctx context.Context, call *Call) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+
+	start := time.Now()
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := rt(context.Background(), &Call{Method: "status"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// 10 requests at 100/s: the first starts now, the last 90ms later.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("10 requests took %v, want at least 90ms", elapsed)
+	}
+
+	if calls != 10 {
+		t.Errorf("got %d requests, want 10", calls)
+	}
+}
+
+func TestLimiterCanceled(t *testing.T) {
+	t.Parallel()
+
+	limit := newLimiter(1, 1)
+	rt := limit.middleware(func(ctx context.Context, call *Call) error { return nil })
+
+	// The first request takes the only reservation for this second.
+	if err := rt(context.Background(), &Call{Method: "status"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := rt(ctx, &Call{Method: "status"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want deadline exceeded", err)
+	}
+
+	// The canceled request returned its reservation, so the next one starts after 1 interval, not 2.
+	limit.mu.Lock()
+	next := time.Until(limit.next)
+	limit.mu.Unlock()
+
+	if next > time.Second {
+		t.Errorf("next request starts in %v, want at most 1s", next)
+	}
+
+	if len(limit.slots) != 0 {
+		t.Errorf("%d request slots still held", len(limit.slots))
+	}
+}
@@ -0,0 +1,220 @@
+package nzbget
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"golift.io/nzbget/internal/list"
+)
+
+// Call is a single RPC request passing through the middleware chain.
+type Call struct {
+	Method string        // RPC method name, like "listgroups".
+	Args   []interface{} // RPC method arguments, in order.
+	Output interface{}   // Pointer the result is decoded into.
+}
+
+// RoundTripFunc makes an RPC call and decodes the result into call.Output.
+type RoundTripFunc func(ctx context.Context, call *Call) error
+
+// Middleware wraps a RoundTripFunc to inspect or modify calls and their results.
+// A middleware may return without calling next to short-circuit a request.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Use adds middleware to every request made with GetInto. The first middleware
+// provided is the outermost; it sees the call first and the result last.
+// Call Use before making requests; it is not safe to call it concurrently with requests.
+func (n *NZBGet) Use(middleware ...Middleware) {
+	n.middleware = append(n.middleware, middleware...)
+	n.roundTrip = chain(n.transport, n.middleware...)
+}
+
+// chain wraps a RoundTripFunc in middleware. The first middleware is the outermost.
+func chain(roundTrip RoundTripFunc, middleware ...Middleware) RoundTripFunc {
+	for idx := len(middleware) - 1; idx >= 0; idx-- {
+		roundTrip = middleware[idx](roundTrip)
+	}
+
+	return roundTrip
+}
+
+// middleware returns the built-in middleware enabled by the Config.
+func (c *Config) middleware(cache *cache) []Middleware {
+	var middleware []Middleware
+
+	if c.ReadOnly || c.DryRun {
+		middleware = append(middleware, c.guardMiddleware())
+	}
+
+	if cache != nil {
+		middleware = append(middleware, cache.middleware)
+	}
+
+	if c.MaxRate > 0 || c.MaxInFlight > 0 {
+		middleware = append(middleware, newLimiter(c.MaxRate, c.MaxInFlight).middleware)
+	}
+
+	return middleware
+}
+
+// Tracer starts spans. Wrap an OpenTelemetry tracer (or any other) to satisfy this interface.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is the part of a tracing span TraceMiddleware uses.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// TraceMiddleware starts a span for every RPC call.
+// Spans are named nzbget.<method> and carry OpenTelemetry RPC attributes.
+func TraceMiddleware(tracer Tracer) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			ctx, span := tracer.Start(ctx, "nzbget."+call.Method)
+			defer span.End()
+
+			span.SetAttribute("rpc.system", "jsonrpc")
+			span.SetAttribute("rpc.service", "nzbget")
+			span.SetAttribute("rpc.method", call.Method)
+
+			start := time.Now()
+			err := next(ctx, call)
+
+			span.SetAttribute("rpc.duration_ms", time.Since(start).Milliseconds())
+
+			if err != nil {
+				span.RecordError(err)
+			}
+
+			return err
+		}
+	}
+}
+
+// Redacted replaces sensitive values in RedactArgs output.
+const Redacted = "********"
+
+// sensitive are lowercase substrings of parameter names that hold credentials.
+var sensitive = []string{"password", "username", "apikey", "secret", "token"} //nolint:gochecknoglobals
+
+// sensitiveQuery are lowercase URL query parameter names that hold credentials,
+// in addition to the sensitive words. Indexers put API keys in NZB URLs.
+var sensitiveQuery = []string{"key", "passkey", "r", "i"} //nolint:gochecknoglobals
+
+// minEncodedNZB is the length at which a base64 string is treated as NZB file content.
+const minEncodedNZB = 256
+
+// RedactArgs returns a copy of RPC arguments with credential values replaced.
+// Parameters (like those passed to saveconfig and append) are returned as name/value
+// pairs, and redacted when their name looks like a password, username or key.
+// URLs have their credentials and key-like query parameters redacted, and base64
+// encoded NZB file content is replaced with its length.
+func RedactArgs(args []interface{}) []interface{} {
+	output := make([]interface{}, len(args))
+
+	for idx, arg := range args {
+		switch val := arg.(type) {
+		case string:
+			output[idx] = redactString(val)
+		case []*Parameter:
+			params := make([][2]string, len(val))
+			for pIdx, param := range val {
+				params[pIdx] = [2]string{param.Name, redact(param.Name, param.Value)}
+			}
+
+			output[idx] = params
+		case [][2]string:
+			params := make([][2]string, len(val))
+			for pIdx, param := range val {
+				params[pIdx] = [2]string{param[0], redact(param[0], param[1])}
+			}
+
+			output[idx] = params
+		default:
+			output[idx] = arg
+		}
+	}
+
+	return output
+}
+
+func redact(name, value string) string {
+	name = strings.ToLower(name)
+
+	for _, word := range sensitive {
+		if strings.Contains(name, word) {
+			return Redacted
+		}
+	}
+
+	return value
+}
+
+// redactString redacts credentials in a URL, and replaces encoded NZB content with its length.
+func redactString(value string) string {
+	if strings.Contains(value, "://") {
+		return redactURL(value)
+	}
+
+	if len(value) >= minEncodedNZB && isBase64(value) {
+		return fmt.Sprintf("[%d bytes of NZB content]", len(value))
+	}
+
+	return value
+}
+
+// redactURL redacts the password and key-like query parameters in a URL. The query order is kept.
+func redactURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+
+	user := parsed.User
+	parsed.User = nil
+
+	params := strings.Split(parsed.RawQuery, "&")
+	for idx, param := range params {
+		name, _, found := strings.Cut(param, "=")
+		if !found {
+			continue
+		}
+
+		lower := strings.ToLower(name)
+		if redact(lower, "") == Redacted || list.Contains(sensitiveQuery, lower) {
+			params[idx] = name + "=" + Redacted
+		}
+	}
+
+	parsed.RawQuery = strings.Join(params, "&")
+	output := parsed.String()
+
+	// url.UserPassword would escape the redaction, so the user info is put back by hand.
+	if _, ok := user.Password(); ok {
+		output = strings.Replace(output, "://", "://"+url.User(user.Username()).String()+":"+Redacted+"@", 1)
+	} else if user != nil {
+		output = strings.Replace(output, "://", "://"+user.String()+"@", 1)
+	}
+
+	return output
+}
+
+func isBase64(value string) bool {
+	for _, char := range value {
+		switch {
+		case char >= 'A' && char <= 'Z', char >= 'a' && char <= 'z', char >= '0' && char <= '9',
+			char == '+', char == '/', char == '=', char == '\r', char == '\n':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,33 @@
+//go:build go1.21
+
+package nzbget
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LogMiddleware logs every RPC call with its method, redacted arguments and duration.
+// Successful calls are logged at debug level, and failed calls at error level.
+func LogMiddleware(logger *slog.Logger) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			start := time.Now()
+			err := next(ctx, call)
+			attrs := []slog.Attr{
+				slog.String("method", call.Method),
+				slog.Any("args", RedactArgs(call.Args)),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			if err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "nzbget request failed", append(attrs, slog.Any("error", err))...)
+			} else {
+				logger.LogAttrs(ctx, slog.LevelDebug, "nzbget request", attrs...)
+			}
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,58 @@
+//go:build go1.21
+
+package nzbget
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	roundTrip := LogMiddleware(logger)(func(_ context.Context, call *Call) error {
+		if call.Method == "append" {
+			return errors.New("failed")
+		}
+
+		return nil
+	})
+
+	_ = roundTrip(context.Background(), &Call{Method: "status"})
+	_ = roundTrip(context.Background(), &Call{
+		Method: "append",
+		Args:   []interface{}{"a.nzb", "https://indexer/get?apikey=SECRET"},
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d log lines, want 2: %s", len(lines), buf.String())
+	}
+
+	var entries [2]map[string]interface{}
+	for idx, line := range lines {
+		if err := json.Unmarshal([]byte(line), &entries[idx]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if entries[0]["level"] != "DEBUG" || entries[0]["method"] != "status" || entries[0]["duration"] == nil {
+		t.Errorf("unexpected success entry: %v", entries[0])
+	}
+
+	if entries[1]["level"] != "ERROR" || entries[1]["method"] != "append" || entries[1]["error"] != "failed" {
+		t.Errorf("unexpected failure entry: %v", entries[1])
+	}
+
+	if strings.Contains(lines[1], "SECRET") {
+		t.Errorf("log contains the API key: %s", lines[1])
+	}
+}
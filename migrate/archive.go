@@ -0,0 +1,78 @@
+// Package migrate moves an NZBGet queue between instances. Export captures the queue,
+// with each item's NZB content or URL, and the history metadata in a versioned JSON
+// archive. Import replays the queue into another instance, and reports the items it
+// could not recreate. History can not be recreated through the API, so it is only archived.
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// ArchiveVersion is the version of archives written by this package.
+const ArchiveVersion = 1
+
+// Errors returned by this package.
+var (
+	ErrUnsupportedVersion = errors.New("unsupported archive version")
+	ErrNoContent          = errors.New("item has no nzb content or url")
+	ErrNotAdded           = errors.New("nzbget did not add the item")
+	ErrEmptyNZB           = errors.New("nzb file is empty")
+)
+
+// Archive is a snapshot of an NZBGet queue and history.
+type Archive struct {
+	Version int               `json:"version"`
+	Created time.Time         `json:"created"`
+	Source  string            `json:"source"` // NZBGet version the archive was exported from.
+	Queue   []*Item           `json:"queue"`
+	History []*nzbget.History `json:"history"`
+}
+
+// Item is one queued download.
+type Item struct {
+	NZBID      int64               `json:"nzbId"` // ID on the source instance.
+	Name       string              `json:"name"`
+	Filename   string              `json:"filename"`
+	Category   string              `json:"category"`
+	Priority   int64               `json:"priority"`
+	Paused     bool                `json:"paused"`
+	DupeKey    string              `json:"dupeKey"`
+	DupeScore  int64               `json:"dupeScore"`
+	DupeMode   string              `json:"dupeMode"`
+	Parameters []*nzbget.Parameter `json:"parameters"`
+	URL        string              `json:"url,omitempty"`
+	NZB        []byte              `json:"nzb,omitempty"`      // stored NZB file content, base64 in JSON.
+	NZBError   string              `json:"nzbError,omitempty"` // error reading the NZB file, if any.
+}
+
+// Write writes the archive as JSON.
+func (a *Archive) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(a); err != nil {
+		return fmt.Errorf("encoding archive: %w", err)
+	}
+
+	return nil
+}
+
+// Read reads an archive written by Write.
+func Read(r io.Reader) (*Archive, error) {
+	var archive Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("decoding archive: %w", err)
+	}
+
+	if archive.Version < 1 || archive.Version > ArchiveVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, archive.Version)
+	}
+
+	return &archive, nil
+}
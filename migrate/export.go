@@ -0,0 +1,184 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// ExportClient is the part of *nzbget.NZBGet Export uses.
+type ExportClient interface {
+	VersionContext(ctx context.Context) (string, error)
+	ListGroupsContext(ctx context.Context) ([]*nzbget.Group, error)
+	HistoryContext(ctx context.Context, hidden bool) ([]*nzbget.History, error)
+	ConfigContext(ctx context.Context) ([]*nzbget.Parameter, error)
+}
+
+// ExportConfig is the optional input for Export.
+type ExportConfig struct {
+	// NZBDir is where NZBGet keeps the NZB files it is downloading.
+	// Default: NZBGet's NzbDir setting, which only works on the NZBGet host.
+	NZBDir string
+	// ReadNZB returns the NZB file content for a group. Use this if the NZB files
+	// are not in a local directory. Default: read the file from NZBDir.
+	ReadNZB func(group *nzbget.Group) ([]byte, error)
+	// SkipHistory leaves the history out of the archive.
+	SkipHistory bool
+}
+
+// Export returns an archive of the queue and history. Items get their stored NZB file
+// when one is found, otherwise their URL. Items with neither are still exported, and
+// reported by Import. Items whose NZB file could not be read have the error in NZBError. Groups that finished downloading and are in post-processing are
+// skipped, because their NZB file was already processed.
+func Export(ctx context.Context, client ExportClient, config *ExportConfig) (*Archive, error) {
+	if config == nil {
+		config = &ExportConfig{}
+	}
+
+	version, err := client.VersionContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting version: %w", err)
+	}
+
+	groups, err := client.ListGroupsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting queue: %w", err)
+	}
+
+	readNZB := config.ReadNZB
+	if readNZB == nil {
+		dir, err := nzbDir(ctx, client, config.NZBDir)
+		if err != nil {
+			return nil, err
+		}
+
+		readNZB = func(group *nzbget.Group) ([]byte, error) { return readNZBFile(dir, group) }
+	}
+
+	archive := &Archive{
+		Version: ArchiveVersion,
+		Created: time.Now(),
+		Source:  version,
+		Queue:   []*Item{},
+		History: []*nzbget.History{},
+	}
+
+	for _, group := range groups {
+		if queued(group.Status) {
+			archive.Queue = append(archive.Queue, newItem(group, readNZB))
+		}
+	}
+
+	if !config.SkipHistory {
+		if archive.History, err = client.HistoryContext(ctx, true); err != nil {
+			return nil, fmt.Errorf("getting history: %w", err)
+		}
+	}
+
+	return archive, nil
+}
+
+// queued returns true for groups that have not finished downloading.
+func queued(status nzbget.GroupStatus) bool {
+	switch status { //nolint:exhaustive
+	case nzbget.GroupQUEUED, nzbget.GroupPAUSED, nzbget.GroupDOWNLOADING, nzbget.GroupFETCHING:
+		return true
+	default:
+		return false
+	}
+}
+
+func newItem(group *nzbget.Group, readNZB func(*nzbget.Group) ([]byte, error)) *Item {
+	item := &Item{
+		NZBID:      group.NZBID,
+		Name:       group.NZBName,
+		Filename:   filepath.Base(group.NZBFilename),
+		Category:   group.Category,
+		Priority:   group.MaxPriority,
+		Paused:     group.Status == nzbget.GroupPAUSED,
+		DupeKey:    group.DupeKey,
+		DupeScore:  group.DupeScore,
+		DupeMode:   group.DupeMode,
+		Parameters: make([]*nzbget.Parameter, len(group.Parameters)),
+		URL:        group.URL,
+	}
+
+	if group.NZBFilename == "" {
+		item.Filename = ""
+	}
+
+	for idx := range group.Parameters {
+		item.Parameters[idx] = &group.Parameters[idx]
+	}
+
+	// URL items are still being fetched, so there is no NZB file yet.
+	if group.Status != nzbget.GroupFETCHING {
+		data, err := readNZB(group)
+
+		switch {
+		case err != nil:
+			item.NZBError = fmt.Sprintf("reading nzb: %v", err)
+		case len(data) == 0:
+			item.NZBError = ErrEmptyNZB.Error()
+		default:
+			item.NZB = data
+		}
+	}
+
+	return item
+}
+
+// nzbDir returns dir, or NZBGet's NzbDir setting if dir is empty.
+func nzbDir(ctx context.Context, client ExportClient, dir string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+
+	config, err := client.ConfigContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getting config: %w", err)
+	}
+
+	for _, param := range config {
+		if param.Name == "NzbDir" {
+			return param.Value, nil
+		}
+	}
+
+	return "", nil
+}
+
+// readNZBFile reads a group's NZB file from dir. NZBGet renames the files it
+// queues to name.nzb.queued, and to name.nzb.processed once they are done.
+func readNZBFile(dir string, group *nzbget.Group) ([]byte, error) {
+	name := filepath.Base(group.NZBFilename)
+	if !strings.HasSuffix(strings.ToLower(name), ".nzb") {
+		name += ".nzb"
+	}
+
+	paths := []string{group.NZBFilename}
+
+	if dir != "" {
+		for _, suffix := range []string{".queued", "", ".processed"} {
+			paths = append(paths, filepath.Join(dir, name+suffix))
+		}
+	}
+
+	for _, path := range paths {
+		if path == "" || !filepath.IsAbs(path) {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return data, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+}
@@ -0,0 +1,125 @@
+package migrate
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golift.io/nzbget"
+)
+
+// ImportClient is the part of *nzbget.NZBGet Import uses.
+type ImportClient interface {
+	AppendContext(ctx context.Context, input *nzbget.AppendInput) (int64, error)
+	EditQueueContext(ctx context.Context, command, parameter string, ids []int64) (bool, error)
+}
+
+// ImportConfig is the optional input for Import.
+type ImportConfig struct {
+	Categories map[string]string // renames categories that differ on the new instance.
+	Paused     bool              // add every item paused, to check the queue before it starts.
+}
+
+// Result is the outcome of importing one item.
+type Result struct {
+	Item  *Item
+	NZBID int64 // ID on the new instance, 0 if the item was not added.
+	Err   error
+}
+
+// Report lists the outcome of an import.
+type Report struct {
+	Results []*Result
+}
+
+// Failed returns the items that could not be recreated.
+func (r *Report) Failed() []*Result {
+	failed := []*Result{}
+
+	for _, result := range r.Results {
+		if result.NZBID == 0 {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// Import replays the archived queue into an NZBGet instance, in queue order. Items are added with
+// their NZB file, or their URL if the file was not archived. Items that were renamed on the source
+// instance get their name back, and an error on that edit is reported with the added item.
+// Import stops early only if the context ends; other errors are recorded in the report.
+func Import(ctx context.Context, client ImportClient, archive *Archive, config *ImportConfig) (*Report, error) {
+	if config == nil {
+		config = &ImportConfig{}
+	}
+
+	report := &Report{Results: make([]*Result, 0, len(archive.Queue))}
+
+	for _, item := range archive.Queue {
+		if err := ctx.Err(); err != nil {
+			return report, fmt.Errorf("importing queue: %w", err)
+		}
+
+		report.Results = append(report.Results, importItem(ctx, client, item, config))
+	}
+
+	return report, nil
+}
+
+func importItem(ctx context.Context, client ImportClient, item *Item, config *ImportConfig) *Result {
+	result := &Result{Item: item}
+	input := &nzbget.AppendInput{
+		Filename:   item.Filename,
+		Category:   item.Category,
+		Priority:   item.Priority,
+		AddPaused:  item.Paused || config.Paused,
+		DupeKey:    item.DupeKey,
+		DupeScore:  item.DupeScore,
+		DupeMode:   item.DupeMode,
+		Parameters: item.Parameters,
+	}
+
+	if category, ok := config.Categories[item.Category]; ok {
+		input.Category = category
+	}
+
+	switch {
+	case len(item.NZB) > 0:
+		input.Content = base64.StdEncoding.EncodeToString(item.NZB)
+	case item.URL != "":
+		input.Content = item.URL
+	case item.NZBError != "":
+		result.Err = fmt.Errorf("%w: %s", ErrNoContent, item.NZBError)
+		return result
+	default:
+		result.Err = ErrNoContent
+		return result
+	}
+
+	// SCORE is NZBGet's default duplicate mode.
+	if input.DupeMode == "" {
+		input.DupeMode = "SCORE"
+	}
+
+	nzbID, err := client.AppendContext(ctx, input)
+	if err != nil {
+		result.Err = fmt.Errorf("appending: %w", err)
+		return result
+	} else if nzbID <= 0 {
+		result.Err = ErrNotAdded
+		return result
+	}
+
+	result.NZBID = nzbID
+
+	// The name of an item added from a file comes from the file name, which may not match.
+	if len(item.NZB) > 0 && item.Name != "" && item.Name != strings.TrimSuffix(item.Filename, ".nzb") {
+		if _, err := client.EditQueueContext(ctx, "GroupSetName", item.Name, []int64{nzbID}); err != nil {
+			result.Err = fmt.Errorf("restoring name: %w", err)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,261 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golift.io/nzbget"
+)
+
+// fakeSource is the NZBGet instance an archive is exported from.
+type fakeSource struct {
+	groups  []*nzbget.Group
+	history []*nzbget.History
+	config  []*nzbget.Parameter
+}
+
+func (f *fakeSource) VersionContext(context.Context) (string, error) { return "21.1", nil }
+
+func (f *fakeSource) ListGroupsContext(context.Context) ([]*nzbget.Group, error) {
+	return f.groups, nil
+}
+
+func (f *fakeSource) HistoryContext(context.Context, bool) ([]*nzbget.History, error) {
+	return f.history, nil
+}
+
+func (f *fakeSource) ConfigContext(context.Context) ([]*nzbget.Parameter, error) {
+	return f.config, nil
+}
+
+// fakeTarget is the NZBGet instance an archive is imported into.
+type fakeTarget struct {
+	appends []*nzbget.AppendInput
+	edits   []string
+	reject  string // Filename or URL NZBGet refuses to add.
+}
+
+func (f *fakeTarget) AppendContext(_ context.Context, input *nzbget.AppendInput) (int64, error) {
+	if f.reject != "" && (input.Filename == f.reject || input.Content == f.reject) {
+		return 0, nil
+	}
+
+	f.appends = append(f.appends, input)
+
+	return int64(100 + len(f.appends)), nil
+}
+
+func (f *fakeTarget) EditQueueContext(_ context.Context, command, param string, _ []int64) (bool, error) {
+	f.edits = append(f.edits, command+"="+param)
+	return true, nil
+}
+
+var errUnreadable = errors.New("permission denied")
+
+func sourceQueue() *fakeSource {
+	return &fakeSource{
+		groups: []*nzbget.Group{
+			{NZBID: 1, NZBName: "Renamed.Show", NZBFilename: "/nzb/Show.S01E01.nzb", Category: "tv",
+				MaxPriority: 50, Status: nzbget.GroupQUEUED, DupeKey: "tt1", DupeScore: 10, DupeMode: "ALL",
+				Parameters: []nzbget.Parameter{{Name: "*Unpack:", Value: "yes"}}},
+			{NZBID: 2, NZBName: "Paused.Movie", NZBFilename: "Paused.Movie.nzb", Category: "movies",
+				Status: nzbget.GroupPAUSED, URL: "https://indexer/get/2"},
+			{NZBID: 3, NZBName: "Fetching", Status: nzbget.GroupFETCHING, URL: "https://indexer/get/3"},
+			{NZBID: 4, NZBName: "Unreadable", NZBFilename: "Unreadable.nzb", Status: nzbget.GroupQUEUED},
+			{NZBID: 5, NZBName: "Empty", NZBFilename: "Empty.nzb", Status: nzbget.GroupQUEUED},
+			{NZBID: 6, NZBName: "Unpacking", NZBFilename: "Unpacking.nzb", Status: nzbget.GroupUNPACKING},
+		},
+		history: []*nzbget.History{{NZBID: 9, Name: "Done", Status: "SUCCESS/ALL"}},
+	}
+}
+
+func readNZB(group *nzbget.Group) ([]byte, error) {
+	switch group.NZBName {
+	case "Renamed.Show":
+		return []byte("<nzb>show</nzb>"), nil
+	case "Unreadable":
+		return nil, errUnreadable
+	case "Empty":
+		return []byte{}, nil
+	default:
+		return nil, os.ErrNotExist
+	}
+}
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	archive, err := Export(context.Background(), sourceQueue(), &ExportConfig{ReadNZB: readNZB})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if archive.Version != ArchiveVersion || archive.Source != "21.1" || len(archive.History) != 1 {
+		t.Errorf("got archive %+v", archive)
+	}
+
+	if len(archive.Queue) != 5 {
+		t.Fatalf("got %d items, want 5: groups in post-processing are skipped", len(archive.Queue))
+	}
+
+	tests := []struct {
+		nzb, url, nzbError string
+	}{
+		{nzb: "<nzb>show</nzb>"},
+		{url: "https://indexer/get/2", nzbError: "reading nzb: file does not exist"},
+		{url: "https://indexer/get/3"}, // URLs that are still being fetched have no file to read.
+		{nzbError: "reading nzb: permission denied"},
+		{nzbError: ErrEmptyNZB.Error()},
+	}
+
+	for idx, test := range tests {
+		item := archive.Queue[idx]
+		if string(item.NZB) != test.nzb || item.URL != test.url || item.NZBError != test.nzbError {
+			t.Errorf("item %d: got nzb %q, url %q, error %q; want %+v", item.NZBID, item.NZB, item.URL, item.NZBError, test)
+		}
+	}
+
+	if item := archive.Queue[0]; item.Filename != "Show.S01E01.nzb" || len(item.Parameters) != 1 {
+		t.Errorf("got item %+v", item)
+	}
+
+	archive, err = Export(context.Background(), sourceQueue(), &ExportConfig{ReadNZB: readNZB, SkipHistory: true})
+	if err != nil || len(archive.History) != 0 {
+		t.Errorf("got %d history items, %v; want none", len(archive.History), err)
+	}
+}
+
+func TestReadNZBFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Show.nzb.queued"), []byte("queued"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &fakeSource{
+		groups: []*nzbget.Group{
+			{NZBID: 1, NZBName: "Show", NZBFilename: "Show", Status: nzbget.GroupQUEUED},
+			{NZBID: 2, NZBName: "Gone", NZBFilename: "Gone.nzb", Status: nzbget.GroupQUEUED},
+		},
+		config: []*nzbget.Parameter{{Name: "NzbDir", Value: dir}},
+	}
+
+	archive, err := Export(context.Background(), source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item := archive.Queue[0]; string(item.NZB) != "queued" || item.NZBError != "" {
+		t.Errorf("got %q, %q; want the queued file from NzbDir", item.NZB, item.NZBError)
+	}
+
+	if item := archive.Queue[1]; item.NZB != nil || !strings.Contains(item.NZBError, "Gone.nzb") {
+		t.Errorf("got %q, %q; want a read error naming the file", item.NZB, item.NZBError)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	t.Parallel()
+
+	exported, err := Export(context.Background(), sourceQueue(), &ExportConfig{ReadNZB: readNZB})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := exported.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := &fakeTarget{reject: "https://indexer/get/3"}
+
+	report, err := Import(context.Background(), target, archive, &ImportConfig{Categories: map[string]string{"movies": "films"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Results) != 5 || len(target.appends) != 2 {
+		t.Fatalf("got %d results and %d appends, want 5 and 2", len(report.Results), len(target.appends))
+	}
+
+	show := target.appends[0]
+	if show.Filename != "Show.S01E01.nzb" || show.Content != base64.StdEncoding.EncodeToString([]byte("<nzb>show</nzb>")) ||
+		show.Category != "tv" || show.Priority != 50 || show.AddPaused ||
+		show.DupeKey != "tt1" || show.DupeScore != 10 || show.DupeMode != "ALL" ||
+		len(show.Parameters) != 1 || show.Parameters[0].Name != "*Unpack:" {
+		t.Errorf("got append %+v", show)
+	}
+
+	movie := target.appends[1]
+	if movie.Content != "https://indexer/get/2" || movie.Category != "films" || !movie.AddPaused || movie.DupeMode != "SCORE" {
+		t.Errorf("got append %+v", movie)
+	}
+
+	if len(target.edits) != 1 || target.edits[0] != "GroupSetName=Renamed.Show" {
+		t.Errorf("got edits %v, want the file item renamed", target.edits)
+	}
+
+	failed := report.Failed()
+	if len(failed) != 3 {
+		t.Fatalf("got %d failed items, want 3", len(failed))
+	}
+
+	for idx, want := range []struct {
+		nzbID int64
+		err   error
+		msg   string
+	}{
+		{nzbID: 3, err: ErrNotAdded},
+		{nzbID: 4, err: ErrNoContent, msg: "permission denied"},
+		{nzbID: 5, err: ErrNoContent, msg: ErrEmptyNZB.Error()},
+	} {
+		if result := failed[idx]; result.Item.NZBID != want.nzbID || !errors.Is(result.Err, want.err) ||
+			!strings.Contains(result.Err.Error(), want.msg) {
+			t.Errorf("failure %d: got item %d, %v; want item %d, %v %s", idx, result.Item.NZBID, result.Err, want.nzbID, want.err, want.msg)
+		}
+	}
+}
+
+func TestImportPaused(t *testing.T) {
+	t.Parallel()
+
+	archive := &Archive{Version: ArchiveVersion, Queue: []*Item{{Name: "Show", URL: "https://indexer/get/1"}}}
+	target := &fakeTarget{}
+
+	if _, err := Import(context.Background(), target, archive, &ImportConfig{Paused: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !target.appends[0].AddPaused {
+		t.Error("the item was not added paused")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if report, err := Import(ctx, target, archive, nil); !errors.Is(err, context.Canceled) || len(report.Results) != 0 {
+		t.Errorf("got %d results, %v; want a canceled import", len(report.Results), err)
+	}
+}
+
+func TestReadVersion(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []string{`{"version":0}`, `{"version":2}`} {
+		if _, err := Read(strings.NewReader(body)); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("%s: got %v, want ErrUnsupportedVersion", body, err)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+// Package notifier posts JSON messages to webhooks when NZBGet downloads finish or fail.
+// It polls NZBGet's history for new items, routes them to webhooks by category and
+// event, and signs each message with a shared secret so receivers can verify it.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/internal/list"
+	"golift.io/nzbget/internal/poll"
+)
+
+// Package defaults.
+const (
+	DefaultInterval   = time.Minute
+	DefaultRetries    = 3
+	DefaultRetryDelay = 5 * time.Second
+	DefaultTimeout    = 30 * time.Second
+)
+
+// Headers sent with every webhook request.
+const (
+	SignatureHeader = "X-Nzbget-Signature" // sha256=<hex HMAC-SHA256 of the body>, if the webhook has a Secret.
+	EventHeader     = "X-Nzbget-Event"
+)
+
+// ErrBadStatus is returned when a webhook responds with a non-2xx status.
+var ErrBadStatus = errors.New("webhook returned a bad status")
+
+// Event is the kind of history change a message is about.
+type Event string
+
+// Events go here. They match NZBGet's history status families.
+const (
+	EventCompleted Event = "completed" // SUCCESS
+	EventWarning   Event = "warning"   // WARNING
+	EventFailed    Event = "failed"    // FAILURE
+	EventDeleted   Event = "deleted"   // DELETED
+)
+
+// Client is the part of *nzbget.NZBGet the Notifier uses.
+type Client interface {
+	HistoryContext(ctx context.Context, hidden bool) ([]*nzbget.History, error)
+}
+
+// Webhook is a destination for messages.
+//
+//nolint:lll
+type Webhook struct {
+	URL        string            `json:"url"        toml:"url"        xml:"url"        yaml:"url"`
+	Secret     string            `json:"secret"     toml:"secret"     xml:"secret"     yaml:"secret"`     // optional, signs the body.
+	Categories []string          `json:"categories" toml:"categories" xml:"category"   yaml:"categories"` // optional, empty is every category.
+	Events     []Event           `json:"events"     toml:"events"     xml:"event"      yaml:"events"`     // optional, empty is every event.
+	Template   string            `json:"template"   toml:"template"   xml:"template"   yaml:"template"`   // optional text/template for the body, executed with a *Payload.
+	Headers    map[string]string `json:"headers"    toml:"headers"    xml:"-"          yaml:"headers"`    // optional, extra request headers.
+	tmpl       *template.Template
+}
+
+// Config is the input data needed to return a Notifier.
+type Config struct {
+	Webhooks   []*Webhook
+	Interval   time.Duration // default: DefaultInterval
+	Retries    int           // attempts after the first failure, default: DefaultRetries, negative disables retries.
+	RetryDelay time.Duration // doubles after each retry, default: DefaultRetryDelay
+	Backfill   bool          // notify about history that existed before the first check.
+	Client     *http.Client  // optional, default: an http.Client with DefaultTimeout.
+	Logger     nzbget.Logger // optional, default: log.Default()
+}
+
+// Payload is the default message body, and the data passed to webhook templates.
+type Payload struct {
+	Event       Event           `json:"event"`
+	NZBID       int64           `json:"nzbId"`
+	Name        string          `json:"name"`
+	Category    string          `json:"category"`
+	Status      string          `json:"status"`
+	SizeMB      int64           `json:"sizeMb"`
+	Health      int64           `json:"health"` // per mille.
+	DownloadSec int64           `json:"downloadSec"`
+	FinalDir    string          `json:"finalDir"`
+	Time        time.Time       `json:"time"`
+	History     *nzbget.History `json:"-"` // the whole history item, for templates.
+}
+
+// Notifier watches history and sends messages.
+type Notifier struct {
+	client Client
+	config *Config
+	mu     sync.Mutex
+	cursor nzbget.HistoryCursor
+	primed bool // true after the first check, or SetCursor.
+}
+
+// New returns a Notifier, and parses the webhook templates.
+func New(client Client, config *Config) (*Notifier, error) {
+	for _, hook := range config.Webhooks {
+		if hook.Template == "" {
+			continue
+		}
+
+		tmpl, err := template.New(hook.URL).Funcs(template.FuncMap{"json": toJSON}).Parse(hook.Template)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template for %s: %w", hook.URL, err)
+		}
+
+		hook.tmpl = tmpl
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+
+	if config.Retries < 0 {
+		config.Retries = 0
+	} else if config.Retries == 0 {
+		config.Retries = DefaultRetries
+	}
+
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultRetryDelay
+	}
+
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	return &Notifier{client: client, config: config}, nil
+}
+
+// Run checks history immediately, and then every Interval until the context ends.
+// Errors are logged, and do not stop the notifier.
+func (n *Notifier) Run(ctx context.Context) {
+	poll.Run(ctx, n.config.Interval, n.config.Logger, "notifier", n.Check)
+}
+
+// Check sends messages for history items added since the last check.
+// The first check only records the newest item, unless Backfill is enabled.
+// Failed deliveries are logged and not retried on the next check.
+func (n *Notifier) Check(ctx context.Context) error {
+	items, err := n.advance(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Deliver without holding the lock, so retry delays do not block Cursor and SetCursor.
+	for _, item := range items {
+		for _, err := range n.Notify(ctx, item) {
+			n.config.Logger.Printf("[ERROR] notifier: %s (%d): %v", item.Name, item.NZBID, err)
+		}
+	}
+
+	return nil
+}
+
+// advance moves the cursor past new history items, and returns the items to send messages about.
+func (n *Notifier) advance(ctx context.Context) ([]*nzbget.History, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	history, err := n.client.HistoryContext(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("getting history: %w", err)
+	}
+
+	items := n.cursor.Advance(history)
+	if !n.config.Backfill && !n.primed {
+		items = nil
+	}
+
+	n.primed = true
+
+	return items, nil
+}
+
+// Cursor returns the notifier's place in history. Save it to resume after a restart.
+func (n *Notifier) Cursor() nzbget.HistoryCursor {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return nzbget.HistoryCursor{Since: n.cursor.Since, IDs: append([]int64{}, n.cursor.IDs...)}
+}
+
+// SetCursor sets the notifier's place in history, like one saved from Cursor.
+// The next check sends messages for every item after the cursor, even if the cursor
+// is empty, because an empty cursor saved from Cursor means the history was empty.
+func (n *Notifier) SetCursor(cursor nzbget.HistoryCursor) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.cursor = cursor
+	n.primed = true
+}
+
+// Notify sends a message about a history item to every matching webhook, and returns delivery errors.
+func (n *Notifier) Notify(ctx context.Context, item *nzbget.History) []error {
+	payload := NewPayload(item)
+	errs := []error{}
+
+	for _, hook := range n.config.Webhooks {
+		if !hook.match(payload) {
+			continue
+		}
+
+		if err := n.send(ctx, hook, payload); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hook.URL, err))
+		}
+	}
+
+	return errs
+}
+
+// NewPayload returns the message data for a history item.
+func NewPayload(item *nzbget.History) *Payload {
+	family, _, _ := strings.Cut(item.Status, "/")
+
+	event := map[string]Event{
+		"SUCCESS": EventCompleted,
+		"WARNING": EventWarning,
+		"FAILURE": EventFailed,
+		"DELETED": EventDeleted,
+	}[family]
+
+	return &Payload{
+		Event:       event,
+		NZBID:       item.NZBID,
+		Name:        item.Name,
+		Category:    item.Category,
+		Status:      item.Status,
+		SizeMB:      item.FileSizeMB,
+		Health:      item.Health,
+		DownloadSec: item.DownloadTimeSec,
+		FinalDir:    finalDir(item),
+		Time:        item.HistoryTime.Time,
+		History:     item,
+	}
+}
+
+func finalDir(item *nzbget.History) string {
+	if item.FinalDir != "" {
+		return item.FinalDir
+	}
+
+	return item.DestDir
+}
+
+func (w *Webhook) match(payload *Payload) bool {
+	if len(w.Categories) > 0 && !list.Contains(w.Categories, payload.Category) {
+		return false
+	}
+
+	return len(w.Events) == 0 || list.Contains(w.Events, payload.Event)
+}
+
+// body returns the message body for a webhook.
+func (w *Webhook) body(payload *Payload) ([]byte, error) {
+	if w.tmpl == nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("encoding payload: %w", err)
+		}
+
+		return body, nil
+	}
+
+	var buf bytes.Buffer
+	if err := w.tmpl.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("executing template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// send posts a message to a webhook, retrying with a growing delay until it succeeds.
+func (n *Notifier) send(ctx context.Context, hook *Webhook, payload *Payload) error {
+	body, err := hook.body(payload)
+	if err != nil {
+		return err
+	}
+
+	delay := n.config.RetryDelay
+
+	for attempt := 0; ; attempt++ {
+		if err = n.post(ctx, hook, payload.Event, body); err == nil || attempt >= n.config.Retries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w, retry canceled: %v", err, ctx.Err()) //nolint:errorlint
+		case <-time.After(delay):
+			delay *= 2
+		}
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, hook *Webhook, event Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event))
+
+	for key, val := range hook.Headers {
+		req.Header.Set(key, val)
+	}
+
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	resp, err := n.config.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %s", ErrBadStatus, resp.Status)
+	}
+
+	return nil
+}
+
+// Sign returns the signature header value for a message body: sha256=<hex HMAC-SHA256>.
+// Receivers compute the same value with the shared secret and compare it with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if the signature matches the body. Use this in webhook receivers.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// toJSON is a template function that encodes a value as JSON, for safely quoting strings.
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err //nolint:wrapcheck
+}
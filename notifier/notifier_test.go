@@ -0,0 +1,155 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+type fakeClient struct {
+	history []*nzbget.History
+}
+
+func (f *fakeClient) HistoryContext(context.Context, bool) ([]*nzbget.History, error) {
+	return f.history, nil
+}
+
+// webhook returns a test server that records the NZBIDs it receives.
+func webhook(t *testing.T) (*httptest.Server, func() []int64) {
+	t.Helper()
+
+	var (
+		mu  sync.Mutex
+		ids []int64
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload Payload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+
+		mu.Lock()
+		ids = append(ids, payload.NZBID)
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []int64 {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]int64{}, ids...)
+	}
+}
+
+func TestCheckAfterEmptyHistory(t *testing.T) {
+	t.Parallel()
+
+	server, received := webhook(t)
+	client := &fakeClient{}
+
+	notifier, err := New(client, &Config{Webhooks: []*Webhook{{URL: server.URL}}, Logger: log.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := notifier.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	client.history = []*nzbget.History{{NZBID: 7, Status: "FAILURE/PAR", HistoryTime: nzbget.Time{Time: time.Now()}}}
+
+	if err := notifier.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := received(); len(ids) != 1 || ids[0] != 7 {
+		t.Fatalf("expected a message for item 7 after an empty first check, got: %v", ids)
+	}
+}
+
+func TestSetCursor(t *testing.T) {
+	t.Parallel()
+
+	server, received := webhook(t)
+	client := &fakeClient{history: []*nzbget.History{
+		{NZBID: 2, Status: "SUCCESS/ALL", HistoryTime: nzbget.Time{Time: time.Unix(1700000100, 0)}},
+		{NZBID: 1, Status: "SUCCESS/ALL", HistoryTime: nzbget.Time{Time: time.Unix(1700000000, 0)}},
+	}}
+
+	notifier, err := New(client, &Config{Webhooks: []*Webhook{{URL: server.URL}}, Logger: log.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A cursor saved from a previous run resumes after item 1.
+	notifier.SetCursor(nzbget.HistoryCursor{Since: time.Unix(1700000000, 0), IDs: []int64{1}})
+
+	if err := notifier.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := received(); len(ids) != 1 || ids[0] != 2 {
+		t.Fatalf("expected a message for item 2, got: %v", ids)
+	}
+}
+
+func TestCheckDeliversWithoutLock(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	t.Cleanup(server.Close)
+
+	client := &fakeClient{history: []*nzbget.History{
+		{NZBID: 1, Status: "SUCCESS/ALL", HistoryTime: nzbget.Time{Time: time.Unix(1700000000, 0)}},
+	}}
+
+	notifier, err := New(client, &Config{
+		Webhooks: []*Webhook{{URL: server.URL}},
+		Backfill: true,
+		Logger:   log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checked := make(chan error, 1)
+
+	go func() { checked <- notifier.Check(context.Background()) }()
+
+	<-started
+
+	// The cursor is already past the item being delivered, and reading it does not wait for the delivery.
+	cursor := make(chan nzbget.HistoryCursor, 1)
+
+	go func() { cursor <- notifier.Cursor() }()
+
+	select {
+	case got := <-cursor:
+		if !got.Since.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("got cursor %v, want the delivered item", got.Since)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cursor blocked while a message was being delivered")
+	}
+
+	close(release)
+
+	if err := <-checked; err != nil {
+		t.Fatal(err)
+	}
+}
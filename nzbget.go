@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -19,17 +20,54 @@ const (
 
 // Config is the input data needed to return a NZBGet struct.
 // This is setup to allow you to easily pass this data in from a config file.
+// The Timeout, TLS and DialContext settings are only used when Client is nil.
+// The URL may also be a unix socket path, like unix:///var/run/nzbget.sock,
+// but not with a custom Client.
+// Timeout and the CacheTTL values are time.Duration, so config files decoded with
+// encoding/json, TOML or YAML libraries must give them as integer nanoseconds.
+//
+//nolint:lll
 type Config struct {
-	URL    string       `json:"url"      toml:"url"  xml:"url"  yaml:"url"`
-	User   string       `json:"username" toml:"user" xml:"user" yaml:"user"`
-	Pass   string       `json:"password" toml:"pass" xml:"pass" yaml:"pass"`
-	Client *http.Client `json:"-" toml:"-" xml:"-" yaml:"-"` // optional.
+	URL           string        `json:"url"           toml:"url"             xml:"url"             yaml:"url"`
+	User          string        `json:"username"      toml:"user"            xml:"user"            yaml:"user"`
+	Pass          string        `json:"password"      toml:"pass"            xml:"pass"            yaml:"pass"`
+	Timeout       time.Duration `json:"timeout"       toml:"timeout"         xml:"timeout"         yaml:"timeout"`       // default: DefaultTimeout
+	CAFile        string        `json:"caFile"        toml:"ca_file"         xml:"ca_file"         yaml:"caFile"`        // PEM bundle of CAs to trust.
+	CertFile      string        `json:"certFile"      toml:"cert_file"       xml:"cert_file"       yaml:"certFile"`      // client certificate for mTLS.
+	KeyFile       string        `json:"keyFile"       toml:"key_file"        xml:"key_file"        yaml:"keyFile"`       // client certificate key for mTLS.
+	ServerName    string        `json:"serverName"    toml:"server_name"     xml:"server_name"     yaml:"serverName"`    // overrides the name used to verify the server.
+	TLSMinVersion string        `json:"tlsMinVersion" toml:"tls_min_version" xml:"tls_min_version" yaml:"tlsMinVersion"` // 1.0, 1.1, 1.2 or 1.3.
+	Insecure      bool          `json:"insecure"      toml:"insecure"        xml:"insecure"        yaml:"insecure"`      // skip certificate verification.
+	ReadOnly      bool          `json:"readOnly"      toml:"read_only"       xml:"read_only"       yaml:"readOnly"`      // refuse mutating methods.
+	DryRun        bool          `json:"dryRun"        toml:"dry_run"         xml:"dry_run"         yaml:"dryRun"`        // log mutating methods instead of calling them.
+	MaxRate       float64       `json:"maxRate"       toml:"max_rate"        xml:"max_rate"        yaml:"maxRate"`       // requests per second, 0 is unlimited.
+	MaxInFlight   int           `json:"maxInFlight"   toml:"max_in_flight"   xml:"max_in_flight"   yaml:"maxInFlight"`   // concurrent requests, 0 is unlimited.
+	Client        *http.Client  `json:"-"             toml:"-"               xml:"-"               yaml:"-"`             // optional.
+	// CacheTTL is optional, and enables response caching per RPC method, like {"status": time.Second}.
+	// Concurrent identical requests for a cached method share one response.
+	// Mutating methods (see IsMutating) made with the same client clear the cache.
+	CacheTTL map[string]time.Duration `json:"cacheTtl" toml:"cache_ttl" xml:"cache_ttl" yaml:"cacheTtl"`
+	// DialContext is optional, and used to create every connection to NZBGet.
+	// Use this to connect through an SSH tunnel or SOCKS proxy.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error) `json:"-" toml:"-" xml:"-" yaml:"-"`
+	// Logger is optional, and prints calls skipped by DryRun. Uses log.Default() if nil.
+	Logger Logger `json:"-" toml:"-" xml:"-" yaml:"-"`
+}
+
+// Logger prints messages. *log.Logger satisfies this interface.
+type Logger interface {
+	Printf(format string, v ...interface{})
 }
 
 // NZBGet is what you get in return for passing in a valid Config to New().
 type NZBGet struct {
-	client *client
-	url    string
+	client     *client
+	url        string
+	err        error         // set when the Config cannot produce a working client.
+	middleware []Middleware  // added with Use().
+	transport  RoundTripFunc // do() wrapped in Config-driven middleware.
+	roundTrip  RoundTripFunc // transport wrapped in middleware from Use().
+	cache      *cache        // nil if Config.CacheTTL is empty.
 }
 
 type client struct {
@@ -37,6 +75,10 @@ type client struct {
 	*http.Client
 }
 
+// New returns an NZBGet client. If the Config contains invalid TLS settings, or a unix
+// socket URL with a custom Client, the error is returned by every request made with the
+// returned client.
+// Use Config.HTTPClient() to validate the TLS settings beforehand.
 func New(config *Config) *NZBGet {
 	// Set username and password if one's configured.
 	auth := config.User + ":" + config.Pass
@@ -46,23 +88,54 @@ func New(config *Config) *NZBGet {
 		auth = ""
 	}
 
+	var err error
+
 	httpClient := config.Client
 	if httpClient == nil {
-		httpClient = &http.Client{}
+		httpClient, err = config.HTTPClient()
+	} else if config.socketPath() != "" {
+		// Only clients built from the Config know how to dial the socket.
+		err = ErrSocketClient
 	}
 
-	return &NZBGet{
-		url: strings.TrimSuffix(strings.TrimSuffix(config.URL, "/"), "/jsonrpc") + "/jsonrpc",
+	nzbget := &NZBGet{
+		url: config.rpcURL(),
+		err: err,
 		client: &client{
 			Auth:   auth,
 			Client: httpClient,
 		},
+		cache: newCache(config.CacheTTL),
+	}
+	nzbget.transport = chain(nzbget.do, config.middleware(nzbget.cache)...)
+	nzbget.roundTrip = nzbget.transport
+
+	return nzbget
+}
+
+// rpcURL returns the URL requests are sent to. Unix sockets
+// are dialed directly, so their URL has a placeholder host.
+func (c *Config) rpcURL() string {
+	if c.socketPath() != "" {
+		return "http://unix/jsonrpc"
 	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(c.URL, "/"), "/jsonrpc") + "/jsonrpc"
 }
 
 // GetInto is a helper method to make a JSON-RPC request and turn the response into structured data.
+// The request passes through any middleware added with Use().
 func (n *NZBGet) GetInto(ctx context.Context, method string, output interface{}, args ...interface{}) error {
-	message, err := json.EncodeClientRequest(method, args)
+	if n.err != nil {
+		return fmt.Errorf("invalid config: %w", n.err)
+	}
+
+	return n.roundTrip(ctx, &Call{Method: method, Args: args, Output: output})
+}
+
+// do makes the HTTP request for an RPC call. This is the end of the middleware chain.
+func (n *NZBGet) do(ctx context.Context, call *Call) error {
+	message, err := json.EncodeClientRequest(call.Method, call.Args)
 	if err != nil {
 		return fmt.Errorf("encoding request: %w", err)
 	}
@@ -85,7 +158,7 @@ func (n *NZBGet) GetInto(ctx context.Context, method string, output interface{},
 	}
 	defer resp.Body.Close()
 
-	if err := json.DecodeClientResponse(resp.Body, &output); err != nil {
+	if err := decodeResponse(resp.Body, call.Output); err != nil {
 		return fmt.Errorf("parsing response: %w: %s", err, resp.Status)
 	}
 
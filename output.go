@@ -261,6 +261,12 @@ type BytesPer struct {
 	SizeMB int64 `json:"SizeMB"` // Amount of downloaded data, in megabytes.
 }
 
+// JoinSize combines the high and low 32 bits of a size NZBGet splits in two, like
+// FileSizeHi and FileSizeLo, into bytes.
+func JoinSize(hi, lo int64) int64 {
+	return hi<<32 | lo //nolint:gomnd
+}
+
 // Time defines a timestamp encoded as epoch seconds in JSON.
 // NZBGet returns all times as seconds since epoch, so we use a custom type to always return a proper go Time.
 type Time struct {
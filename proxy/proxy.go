@@ -0,0 +1,242 @@
+// Package proxy is an authorizing reverse proxy for NZBGet. It authenticates users
+// against its own list, and applies per-user method and category rules before
+// forwarding calls to NZBGet. Queue and history responses are filtered to the
+// categories each user may see.
+package proxy
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/internal/list"
+	"golift.io/nzbget/server"
+)
+
+// User is a proxy user and the rules that apply to it.
+type User struct {
+	Name     string
+	Password string
+	// Allow lists the RPC methods the user may call, like append and listgroups.
+	// If this is empty, every method not in Deny is allowed.
+	Allow []string
+	// Deny lists the RPC methods the user may not call, like shutdown and saveconfig.
+	Deny []string
+	// Categories restricts the user to items in these categories. Items may only be appended
+	// to these categories, and other items are hidden from the queue and history, and can
+	// not be edited. Edit commands for individual files, and edits without NZBIDs, are denied.
+	// The config, loadconfig, saveconfig and log methods expose or change data for every
+	// category, so they are denied unless they are listed in Allow. Empty allows all categories.
+	Categories []string
+}
+
+// unfiltered are methods whose responses can not be filtered by category.
+// Category restricted users may only call them if they are in the user's Allow list.
+var unfiltered = []string{"config", "loadconfig", "saveconfig", "log"} //nolint:gochecknoglobals
+
+// Config is the input data needed to return a Proxy.
+type Config struct {
+	Upstream *nzbget.Config // the NZBGet instance calls are forwarded to.
+	Users    []*User
+	Server   *server.Config // optional, Authenticate is replaced by the proxy.
+}
+
+// Proxy is an http.Handler that forwards authorized NZBGet RPC calls.
+type Proxy struct {
+	*server.Handler
+	users map[string]*User
+}
+
+// New returns a Proxy for the upstream NZBGet instance.
+func New(config *Config) *Proxy {
+	proxy := &Proxy{users: make(map[string]*User, len(config.Users))}
+
+	for _, user := range config.Users {
+		proxy.users[user.Name] = user
+	}
+
+	serverConfig := &server.Config{}
+	if config.Server != nil {
+		*serverConfig = *config.Server
+	}
+
+	serverConfig.Authenticate = proxy.authenticate
+	upstream := nzbget.New(config.Upstream)
+	upstream.Use(proxy.authorize)
+	proxy.Handler = server.New(upstream, serverConfig)
+
+	return proxy
+}
+
+var _ http.Handler = (*Proxy)(nil)
+
+// authenticate checks the credentials against the user list.
+func (p *Proxy) authenticate(username, password string) bool {
+	user := p.users[username]
+	if user == nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) == 1
+}
+
+// denied returns the error sent to callers for calls they are not allowed to make.
+func denied(format string, v ...interface{}) error {
+	return &nzbget.RPCError{
+		Name:    "JSONRPCError",
+		Code:    server.CodeAccessDenied,
+		Message: "access denied: " + fmt.Sprintf(format, v...),
+	}
+}
+
+// authorize is middleware for the upstream client that applies the rules of the user making the call.
+func (p *Proxy) authorize(next nzbget.RoundTripFunc) nzbget.RoundTripFunc {
+	return func(ctx context.Context, call *nzbget.Call) error {
+		user := p.users[server.User(ctx)]
+		if user == nil {
+			return denied("unknown user")
+		}
+
+		if !user.allows(call.Method) {
+			return denied("%s may not call %s", user.Name, call.Method)
+		}
+
+		if len(user.Categories) > 0 {
+			if err := user.checkCall(ctx, next, call); err != nil {
+				return err
+			}
+		}
+
+		if err := next(ctx, call); err != nil {
+			return err
+		}
+
+		if len(user.Categories) > 0 {
+			user.filter(call.Output)
+		}
+
+		return nil
+	}
+}
+
+// allows returns true if the user may call method. Method names are not case sensitive.
+func (u *User) allows(method string) bool {
+	if list.ContainsFold(u.Deny, method) {
+		return false
+	}
+
+	if len(u.Categories) > 0 && list.ContainsFold(unfiltered, method) {
+		return list.ContainsFold(u.Allow, method)
+	}
+
+	return len(u.Allow) == 0 || list.ContainsFold(u.Allow, method)
+}
+
+// hasCategory returns true if the user may see items in category. Categories are not case sensitive.
+func (u *User) hasCategory(category string) bool {
+	return len(u.Categories) == 0 || list.ContainsFold(u.Categories, category)
+}
+
+// checkCall enforces category restrictions on calls that add or touch individual items.
+func (u *User) checkCall(ctx context.Context, next nzbget.RoundTripFunc, call *nzbget.Call) error {
+	switch call.Method {
+	case "append":
+		if category := arg[string](call.Args, 2); !u.hasCategory(category) { //nolint:gomnd
+			return denied("%s may not add to category '%s'", u.Name, category)
+		}
+	case "listfiles":
+		return u.checkIDs(ctx, next, []int64{arg[int64](call.Args, 2)}) //nolint:gomnd
+	case "loadlog":
+		return u.checkIDs(ctx, next, []int64{arg[int64](call.Args, 0)})
+	case "editqueue":
+		command := arg[string](call.Args, 0)
+		if !strings.HasPrefix(command, "Group") && !strings.HasPrefix(command, "History") {
+			return denied("%s may not use edit command %s", u.Name, command)
+		}
+
+		if strings.HasSuffix(command, "Category") && !u.hasCategory(arg[string](call.Args, 1)) {
+			return denied("%s may not move items to category '%s'", u.Name, arg[string](call.Args, 1))
+		}
+
+		return u.checkIDs(ctx, next, arg[[]int64](call.Args, 2)) //nolint:gomnd
+	}
+
+	return nil
+}
+
+// checkIDs returns an error if any of the NZBIDs are not in the queue or history in the user's categories.
+// An empty list is denied, because some edit commands apply to every item without NZBIDs.
+func (u *User) checkIDs(ctx context.Context, next nzbget.RoundTripFunc, ids []int64) error {
+	if len(ids) == 0 {
+		return denied("%s must list the items to access", u.Name)
+	}
+
+	var (
+		groups  []*nzbget.Group
+		history []*nzbget.History
+	)
+
+	if err := next(ctx, &nzbget.Call{Method: "listgroups", Args: []interface{}{0}, Output: &groups}); err != nil {
+		return err
+	}
+
+	if err := next(ctx, &nzbget.Call{Method: "history", Args: []interface{}{true}, Output: &history}); err != nil {
+		return err
+	}
+
+	visible := make(map[int64]bool, len(groups)+len(history))
+
+	for _, group := range groups {
+		visible[group.NZBID] = u.hasCategory(group.Category)
+	}
+
+	for _, item := range history {
+		visible[item.NZBID] = u.hasCategory(item.Category)
+	}
+
+	for _, id := range ids {
+		if !visible[id] {
+			return denied("%s may not access item %d", u.Name, id)
+		}
+	}
+
+	return nil
+}
+
+// filter removes items in other categories from queue and history responses.
+func (u *User) filter(output interface{}) {
+	switch output := output.(type) {
+	case *[]*nzbget.Group:
+		*output = only(*output, func(group *nzbget.Group) bool { return u.hasCategory(group.Category) })
+	case *[]*nzbget.History:
+		*output = only(*output, func(item *nzbget.History) bool { return u.hasCategory(item.Category) })
+	}
+}
+
+func only[T any](list []*T, keep func(*T) bool) []*T {
+	output := make([]*T, 0, len(list))
+
+	for _, item := range list {
+		if keep(item) {
+			output = append(output, item)
+		}
+	}
+
+	return output
+}
+
+// arg returns the call argument at idx, or the zero value if it is missing or another type.
+func arg[T any](args []interface{}, idx int) T {
+	var zero T
+
+	if idx >= len(args) {
+		return zero
+	}
+
+	val, _ := args[idx].(T)
+
+	return val
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/server"
+)
+
+// upstream is a fake NZBGet with one queued and one history item in each of two categories.
+type upstream struct {
+	server.Unimplemented
+	mu     sync.Mutex
+	edited []int64
+}
+
+func (u *upstream) ListGroupsContext(context.Context) ([]*nzbget.Group, error) {
+	return []*nzbget.Group{{NZBID: 1, Category: "tv"}, {NZBID: 2, Category: "movies"}}, nil
+}
+
+func (u *upstream) HistoryContext(context.Context, bool) ([]*nzbget.History, error) {
+	return []*nzbget.History{{NZBID: 3, Category: "TV"}, {NZBID: 4, Category: "movies"}}, nil
+}
+
+func (u *upstream) ConfigContext(context.Context) ([]*nzbget.Parameter, error) {
+	return []*nzbget.Parameter{{Name: "ControlPassword", Value: "secret"}}, nil
+}
+
+func (u *upstream) AppendContext(context.Context, *nzbget.AppendInput) (int64, error) {
+	return 5, nil //nolint:gomnd
+}
+
+func (u *upstream) EditQueueContext(_ context.Context, _, _ string, ids []int64) (bool, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.edited = append(u.edited, ids...)
+
+	return true, nil
+}
+
+// newTestProxy returns clients for the users of a proxy in front of a fake NZBGet, and the proxy URL.
+func newTestProxy(t *testing.T, users ...*User) (map[string]*nzbget.NZBGet, string) {
+	t.Helper()
+
+	logger := log.New(io.Discard, "", 0)
+	nzbgetServer := httptest.NewServer(server.New(&upstream{}, &server.Config{Logger: logger}))
+	t.Cleanup(nzbgetServer.Close)
+
+	proxy := httptest.NewServer(New(&Config{
+		Upstream: &nzbget.Config{URL: nzbgetServer.URL},
+		Users:    users,
+		Server:   &server.Config{Logger: logger},
+	}))
+	t.Cleanup(proxy.Close)
+
+	clients := make(map[string]*nzbget.NZBGet, len(users))
+	for _, user := range users {
+		clients[user.Name] = nzbget.New(&nzbget.Config{URL: proxy.URL, User: user.Name, Pass: user.Password})
+	}
+
+	return clients, proxy.URL
+}
+
+// isDenied returns true if err is an access denied error from the proxy.
+func isDenied(err error) bool {
+	var rpcErr *nzbget.RPCError
+	return errors.As(err, &rpcErr) && rpcErr.Code == server.CodeAccessDenied
+}
+
+func TestProxyMethods(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clients, url := newTestProxy(t,
+		&User{Name: "admin", Password: "a", Deny: []string{"shutdown"}},
+		&User{Name: "viewer", Password: "v", Allow: []string{"listgroups"}},
+	)
+
+	if _, err := clients["admin"].ConfigContext(ctx); err != nil {
+		t.Errorf("admin config: %v", err)
+	}
+
+	if _, err := clients["admin"].ShutdownContext(ctx); !isDenied(err) {
+		t.Errorf("admin shutdown: expected access denied, got: %v", err)
+	}
+
+	if groups, err := clients["viewer"].ListGroupsContext(ctx); err != nil || len(groups) != 2 {
+		t.Errorf("viewer listgroups: got %d groups, %v", len(groups), err)
+	}
+
+	if _, err := clients["viewer"].HistoryContext(ctx, false); !isDenied(err) {
+		t.Errorf("viewer history: expected access denied, got: %v", err)
+	}
+
+	wrong := nzbget.New(&nzbget.Config{URL: url, User: "admin", Pass: "wrong"})
+	if _, err := wrong.ListGroupsContext(ctx); err == nil {
+		t.Error("expected wrong credentials to fail")
+	}
+}
+
+func TestProxyCategories(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clients, _ := newTestProxy(t,
+		&User{Name: "tv", Password: "t", Categories: []string{"tv"}},
+		&User{Name: "tvconfig", Password: "c", Categories: []string{"tv"}, Allow: []string{"config"}},
+	)
+	client := clients["tv"]
+
+	if groups, err := client.ListGroupsContext(ctx); err != nil || len(groups) != 1 || groups[0].NZBID != 1 {
+		t.Errorf("listgroups: expected only group 1, got %d groups, %v", len(groups), err)
+	}
+
+	if history, err := client.HistoryContext(ctx, false); err != nil || len(history) != 1 || history[0].NZBID != 3 {
+		t.Errorf("history: expected only item 3, got %d items, %v", len(history), err)
+	}
+
+	if _, err := client.EditQueueContext(ctx, "GroupPause", "", []int64{1}); err != nil {
+		t.Errorf("editing group 1: %v", err)
+	}
+
+	for name, ids := range map[string][]int64{"other category": {1, 2}, "no ids": nil} {
+		if _, err := client.EditQueueContext(ctx, "GroupPause", "", ids); !isDenied(err) {
+			t.Errorf("editing %s: expected access denied, got: %v", name, err)
+		}
+	}
+
+	if _, err := client.EditQueueContext(ctx, "FileDelete", "", []int64{1}); !isDenied(err) {
+		t.Errorf("file edit: expected access denied, got: %v", err)
+	}
+
+	if _, err := client.EditQueueContext(ctx, "GroupSetCategory", "movies", []int64{1}); !isDenied(err) {
+		t.Errorf("moving to movies: expected access denied, got: %v", err)
+	}
+
+	if _, err := client.AppendContext(ctx, &nzbget.AppendInput{Category: "movies", Content: "x"}); !isDenied(err) {
+		t.Errorf("append to movies: expected access denied, got: %v", err)
+	}
+
+	if id, err := client.AppendContext(ctx, &nzbget.AppendInput{Category: "tv", Content: "x"}); err != nil || id != 5 {
+		t.Errorf("append to tv: got %d, %v", id, err)
+	}
+
+	for _, method := range []func(context.Context) ([]*nzbget.Parameter, error){client.ConfigContext, client.LoadConfigContext} {
+		if _, err := method(ctx); !isDenied(err) {
+			t.Errorf("config: expected access denied, got: %v", err)
+		}
+	}
+
+	if _, err := client.LogContext(ctx, 0, 10); !isDenied(err) {
+		t.Errorf("log: expected access denied, got: %v", err)
+	}
+
+	if _, err := clients["tvconfig"].ConfigContext(ctx); err != nil {
+		t.Errorf("config with explicit allow: %v", err)
+	}
+}
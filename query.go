@@ -0,0 +1,293 @@
+package nzbget
+
+import (
+	"regexp"
+	"sort"
+
+	"golift.io/nzbget/internal/list"
+)
+
+// SortField determines how query results are ordered.
+type SortField int
+
+// SortFields go here. Results keep NZBGet's order when sorting by a field the type does not have.
+const (
+	SortNone     SortField = iota // keep NZBGet's order.
+	SortPriority                  // Group.MaxPriority. Groups only.
+	SortSize                      // smallest first, by FileSizeMB.
+	SortAge                       // oldest first, by Group.MinPostTime or History.HistoryTime.
+	SortProgress                  // Group.Progress(). Groups only.
+	SortName                      // Group.NZBName or History.Name.
+)
+
+// GroupQuery filters, sorts and pages a list of groups from ListGroups.
+// Every filter must match for a group to be included.
+// Filters that accept multiple values match if any one of the values matches.
+type GroupQuery struct {
+	groups  []*Group
+	filters []func(*Group) bool
+	sort    SortField
+	desc    bool
+	offset  int
+	limit   int
+}
+
+// QueryGroups returns a query for the provided groups. Example:
+//
+//	stuck := nzbget.QueryGroups(groups).Category("tv").Unhealthy().Results()
+func QueryGroups(groups []*Group) *GroupQuery {
+	return &GroupQuery{groups: groups}
+}
+
+// Where adds a custom filter to the query.
+func (q *GroupQuery) Where(filter func(*Group) bool) *GroupQuery {
+	q.filters = append(q.filters, filter)
+	return q
+}
+
+// Category matches groups in any of the provided categories.
+func (q *GroupQuery) Category(categories ...string) *GroupQuery {
+	return q.Where(func(group *Group) bool { return list.Contains(categories, group.Category) })
+}
+
+// Status matches groups with any of the provided statuses.
+func (q *GroupQuery) Status(statuses ...GroupStatus) *GroupQuery {
+	return q.Where(func(group *Group) bool { return list.Contains(statuses, group.Status) })
+}
+
+// Name matches groups with an NZBName matching the regular expression.
+func (q *GroupQuery) Name(re *regexp.Regexp) *GroupQuery {
+	return q.Where(func(group *Group) bool { return re.MatchString(group.NZBName) })
+}
+
+// DupeKey matches groups with any of the provided duplicate keys.
+func (q *GroupQuery) DupeKey(keys ...string) *GroupQuery {
+	return q.Where(func(group *Group) bool { return list.Contains(keys, group.DupeKey) })
+}
+
+// Unhealthy matches groups with a Health below their CriticalHealth.
+// These downloads cannot be repaired unless more articles are found.
+func (q *GroupQuery) Unhealthy() *GroupQuery {
+	return q.Where(func(group *Group) bool { return group.Health < group.CriticalHealth })
+}
+
+// SizeMB matches groups with a FileSizeMB from minMB to maxMB, inclusive. A maxMB of 0 has no upper limit.
+func (q *GroupQuery) SizeMB(minMB, maxMB int64) *GroupQuery {
+	return q.Where(func(group *Group) bool { return inRange(group.FileSizeMB, minMB, maxMB) })
+}
+
+// Sort orders the results. Set desc to true to reverse the order.
+func (q *GroupQuery) Sort(field SortField, desc bool) *GroupQuery {
+	q.sort, q.desc = field, desc
+	return q
+}
+
+// Page skips the first offset results and returns at most limit results. A limit of 0 has no limit.
+func (q *GroupQuery) Page(offset, limit int) *GroupQuery {
+	q.offset, q.limit = offset, limit
+	return q
+}
+
+// Results runs the query and returns the matching groups.
+func (q *GroupQuery) Results() []*Group {
+	output := []*Group{}
+
+	for _, group := range q.groups {
+		if q.match(group) {
+			output = append(output, group)
+		}
+	}
+
+	if less := q.less(output); less != nil {
+		sort.SliceStable(output, func(i, j int) bool {
+			if q.desc {
+				return less(j, i)
+			}
+
+			return less(i, j)
+		})
+	}
+
+	start, end := page(len(output), q.offset, q.limit)
+
+	return output[start:end]
+}
+
+func (q *GroupQuery) match(group *Group) bool {
+	for _, filter := range q.filters {
+		if !filter(group) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (q *GroupQuery) less(groups []*Group) func(i, j int) bool {
+	switch q.sort {
+	case SortPriority:
+		return func(i, j int) bool { return groups[i].MaxPriority < groups[j].MaxPriority }
+	case SortSize:
+		return func(i, j int) bool { return groups[i].FileSizeMB < groups[j].FileSizeMB }
+	case SortAge:
+		return func(i, j int) bool { return groups[i].MinPostTime.Before(groups[j].MinPostTime.Time) }
+	case SortProgress:
+		return func(i, j int) bool { return groups[i].Progress() < groups[j].Progress() }
+	case SortName:
+		return func(i, j int) bool { return groups[i].NZBName < groups[j].NZBName }
+	case SortNone:
+		fallthrough
+	default:
+		return nil
+	}
+}
+
+// Progress returns the downloaded percentage of the group, from 0 to 100.
+func (g *Group) Progress() float64 {
+	if g.FileSizeMB <= 0 {
+		return 0
+	}
+
+	return float64(g.FileSizeMB-g.RemainingSizeMB) / float64(g.FileSizeMB) * 100 //nolint:gomnd
+}
+
+// HistoryQuery filters, sorts and pages a list of history items from History.
+// Every filter must match for an item to be included.
+// Filters that accept multiple values match if any one of the values matches.
+type HistoryQuery struct {
+	history []*History
+	filters []func(*History) bool
+	sort    SortField
+	desc    bool
+	offset  int
+	limit   int
+}
+
+// QueryHistory returns a query for the provided history items. Example:
+//
+//	failed := nzbget.QueryHistory(history).Category("tv").Status("FAILURE").Sort(nzbget.SortAge, true).Results()
+func QueryHistory(history []*History) *HistoryQuery {
+	return &HistoryQuery{history: history}
+}
+
+// Where adds a custom filter to the query.
+func (q *HistoryQuery) Where(filter func(*History) bool) *HistoryQuery {
+	q.filters = append(q.filters, filter)
+	return q
+}
+
+// Category matches history items in any of the provided categories.
+func (q *HistoryQuery) Category(categories ...string) *HistoryQuery {
+	return q.Where(func(item *History) bool { return list.Contains(categories, item.Category) })
+}
+
+// Status matches history items with any of the provided statuses. NZBGet's history statuses
+// look like "FAILURE/PAR"; a status family like "FAILURE" matches every status in the family.
+func (q *HistoryQuery) Status(statuses ...string) *HistoryQuery {
+	return q.Where(func(item *History) bool { return list.ContainsFamily(statuses, item.Status) })
+}
+
+// Name matches history items with a Name matching the regular expression.
+func (q *HistoryQuery) Name(re *regexp.Regexp) *HistoryQuery {
+	return q.Where(func(item *History) bool { return re.MatchString(item.Name) })
+}
+
+// DupeKey matches history items with any of the provided duplicate keys.
+func (q *HistoryQuery) DupeKey(keys ...string) *HistoryQuery {
+	return q.Where(func(item *History) bool { return list.Contains(keys, item.DupeKey) })
+}
+
+// Unhealthy matches history items with a Health below their CriticalHealth.
+func (q *HistoryQuery) Unhealthy() *HistoryQuery {
+	return q.Where(func(item *History) bool { return item.Health < item.CriticalHealth })
+}
+
+// SizeMB matches history items with a FileSizeMB from minMB to maxMB, inclusive. A maxMB of 0 has no upper limit.
+func (q *HistoryQuery) SizeMB(minMB, maxMB int64) *HistoryQuery {
+	return q.Where(func(item *History) bool { return inRange(item.FileSizeMB, minMB, maxMB) })
+}
+
+// Sort orders the results. Set desc to true to reverse the order.
+// SortPriority and SortProgress do not apply to history, and keep NZBGet's order.
+func (q *HistoryQuery) Sort(field SortField, desc bool) *HistoryQuery {
+	q.sort, q.desc = field, desc
+	return q
+}
+
+// Page skips the first offset results and returns at most limit results. A limit of 0 has no limit.
+func (q *HistoryQuery) Page(offset, limit int) *HistoryQuery {
+	q.offset, q.limit = offset, limit
+	return q
+}
+
+// Results runs the query and returns the matching history items.
+func (q *HistoryQuery) Results() []*History {
+	output := []*History{}
+
+	for _, item := range q.history {
+		if q.match(item) {
+			output = append(output, item)
+		}
+	}
+
+	if less := q.less(output); less != nil {
+		sort.SliceStable(output, func(i, j int) bool {
+			if q.desc {
+				return less(j, i)
+			}
+
+			return less(i, j)
+		})
+	}
+
+	start, end := page(len(output), q.offset, q.limit)
+
+	return output[start:end]
+}
+
+func (q *HistoryQuery) match(item *History) bool {
+	for _, filter := range q.filters {
+		if !filter(item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (q *HistoryQuery) less(history []*History) func(i, j int) bool {
+	switch q.sort {
+	case SortSize:
+		return func(i, j int) bool { return history[i].FileSizeMB < history[j].FileSizeMB }
+	case SortAge:
+		return func(i, j int) bool { return history[i].HistoryTime.Before(history[j].HistoryTime.Time) }
+	case SortName:
+		return func(i, j int) bool { return history[i].Name < history[j].Name }
+	case SortNone, SortPriority, SortProgress:
+		fallthrough
+	default:
+		return nil
+	}
+}
+
+func inRange(value, minimum, maximum int64) bool {
+	return value >= minimum && (maximum == 0 || value <= maximum)
+}
+
+// page returns the slice bounds for an offset and limit.
+func page(length, offset, limit int) (int, int) {
+	if offset > length {
+		offset = length
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	end := length
+	if limit > 0 && offset+limit < length {
+		end = offset + limit
+	}
+
+	return offset, end
+}
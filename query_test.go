@@ -0,0 +1,175 @@
+package nzbget
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func queryGroups() []*Group {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	return []*Group{
+		{NZBID: 1, NZBName: "Show.S01E01", Category: "tv", Status: GroupDOWNLOADING, FileSizeMB: 700,
+			RemainingSizeMB: 350, MaxPriority: 0, MinPostTime: Time{day.Add(2 * time.Hour)}, Health: 1000, CriticalHealth: 900},
+		{NZBID: 2, NZBName: "Movie.2023", Category: "movies", Status: GroupPAUSED, FileSizeMB: 4000,
+			RemainingSizeMB: 4000, MaxPriority: 100, MinPostTime: Time{day}, Health: 850, CriticalHealth: 900, DupeKey: "tt123"},
+		{NZBID: 3, NZBName: "Show.S01E02", Category: "tv", Status: GroupQUEUED, FileSizeMB: 800,
+			RemainingSizeMB: 200, MaxPriority: 50, MinPostTime: Time{day.Add(time.Hour)}, Health: 1000, CriticalHealth: 900},
+		{NZBID: 4, NZBName: "Album", Category: "music", Status: GroupQUEUED, FileSizeMB: 0, MinPostTime: Time{day.Add(3 * time.Hour)}},
+	}
+}
+
+func groupIDs(groups []*Group) []int64 {
+	ids := []int64{}
+	for _, group := range groups {
+		ids = append(ids, group.NZBID)
+	}
+
+	return ids
+}
+
+func historyIDs(history []*History) []int64 {
+	ids := []int64{}
+	for _, item := range history {
+		ids = append(ids, item.NZBID)
+	}
+
+	return ids
+}
+
+func sameIDs(got, want []int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestGroupQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query func(*GroupQuery) *GroupQuery
+		want  []int64
+	}{
+		{name: "all", query: func(q *GroupQuery) *GroupQuery { return q }, want: []int64{1, 2, 3, 4}},
+		{name: "category", query: func(q *GroupQuery) *GroupQuery { return q.Category("tv", "music") }, want: []int64{1, 3, 4}},
+		{name: "status", query: func(q *GroupQuery) *GroupQuery { return q.Status(GroupPAUSED, GroupDOWNLOADING) }, want: []int64{1, 2}},
+		{name: "name", query: func(q *GroupQuery) *GroupQuery { return q.Name(regexp.MustCompile(`^Show\.`)) }, want: []int64{1, 3}},
+		{name: "dupe key", query: func(q *GroupQuery) *GroupQuery { return q.DupeKey("tt123") }, want: []int64{2}},
+		{name: "unhealthy", query: func(q *GroupQuery) *GroupQuery { return q.Unhealthy() }, want: []int64{2}},
+		{name: "size range", query: func(q *GroupQuery) *GroupQuery { return q.SizeMB(700, 800) }, want: []int64{1, 3}},
+		{name: "size no max", query: func(q *GroupQuery) *GroupQuery { return q.SizeMB(800, 0) }, want: []int64{2, 3}},
+		{name: "every filter", query: func(q *GroupQuery) *GroupQuery { return q.Category("tv").Status(GroupQUEUED) }, want: []int64{3}},
+		{name: "no match", query: func(q *GroupQuery) *GroupQuery { return q.Category("books") }, want: []int64{}},
+		{name: "sort priority", query: func(q *GroupQuery) *GroupQuery { return q.Sort(SortPriority, true) }, want: []int64{2, 3, 1, 4}},
+		{name: "sort size", query: func(q *GroupQuery) *GroupQuery { return q.Sort(SortSize, false) }, want: []int64{4, 1, 3, 2}},
+		{name: "sort age", query: func(q *GroupQuery) *GroupQuery { return q.Sort(SortAge, false) }, want: []int64{2, 3, 1, 4}},
+		{name: "sort progress", query: func(q *GroupQuery) *GroupQuery { return q.Sort(SortProgress, true) }, want: []int64{3, 1, 2, 4}},
+		{name: "sort name", query: func(q *GroupQuery) *GroupQuery { return q.Sort(SortName, false) }, want: []int64{4, 2, 1, 3}},
+		{name: "page", query: func(q *GroupQuery) *GroupQuery { return q.Sort(SortSize, false).Page(1, 2) }, want: []int64{1, 3}},
+		{name: "page past end", query: func(q *GroupQuery) *GroupQuery { return q.Page(10, 2) }, want: []int64{}},
+		{name: "negative limit", query: func(q *GroupQuery) *GroupQuery { return q.Page(2, -1) }, want: []int64{3, 4}},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := groupIDs(test.query(QueryGroups(queryGroups())).Results()); !sameIDs(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHistoryQuery(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	history := func() []*History {
+		return []*History{
+			{NZBID: 1, Name: "Show.S01E01", Category: "tv", Status: "SUCCESS/ALL", FileSizeMB: 700,
+				HistoryTime: Time{day.Add(time.Hour)}, Health: 1000, CriticalHealth: 900},
+			{NZBID: 2, Name: "Movie.2023", Category: "movies", Status: "FAILURE/PAR", FileSizeMB: 4000,
+				HistoryTime: Time{day}, Health: 800, CriticalHealth: 900, DupeKey: "tt123"},
+			{NZBID: 3, Name: "Show.S01E02", Category: "tv", Status: "FAILURE/UNPACK", FileSizeMB: 800,
+				HistoryTime: Time{day.Add(2 * time.Hour)}, Health: 1000, CriticalHealth: 900},
+			{NZBID: 4, Name: "FAILURE", Category: "music", Status: "FAILUREX/OTHER", FileSizeMB: 100,
+				HistoryTime: Time{day.Add(3 * time.Hour)}},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query func(*HistoryQuery) *HistoryQuery
+		want  []int64
+	}{
+		{name: "all", query: func(q *HistoryQuery) *HistoryQuery { return q }, want: []int64{1, 2, 3, 4}},
+		{name: "category", query: func(q *HistoryQuery) *HistoryQuery { return q.Category("tv") }, want: []int64{1, 3}},
+		{name: "status family", query: func(q *HistoryQuery) *HistoryQuery { return q.Status("FAILURE") }, want: []int64{2, 3}},
+		{name: "exact status", query: func(q *HistoryQuery) *HistoryQuery { return q.Status("FAILURE/PAR", "SUCCESS/ALL") }, want: []int64{1, 2}},
+		{name: "name", query: func(q *HistoryQuery) *HistoryQuery { return q.Name(regexp.MustCompile(`S01E02`)) }, want: []int64{3}},
+		{name: "dupe key", query: func(q *HistoryQuery) *HistoryQuery { return q.DupeKey("tt123", "tt999") }, want: []int64{2}},
+		{name: "unhealthy", query: func(q *HistoryQuery) *HistoryQuery { return q.Unhealthy() }, want: []int64{2}},
+		{name: "size", query: func(q *HistoryQuery) *HistoryQuery { return q.SizeMB(0, 800) }, want: []int64{1, 3, 4}},
+		{name: "sort age desc", query: func(q *HistoryQuery) *HistoryQuery { return q.Sort(SortAge, true) }, want: []int64{4, 3, 1, 2}},
+		{name: "sort size", query: func(q *HistoryQuery) *HistoryQuery { return q.Sort(SortSize, false) }, want: []int64{4, 1, 3, 2}},
+		{name: "sort name", query: func(q *HistoryQuery) *HistoryQuery { return q.Sort(SortName, false) }, want: []int64{4, 2, 1, 3}},
+		{name: "sort priority keeps order", query: func(q *HistoryQuery) *HistoryQuery { return q.Sort(SortPriority, true) }, want: []int64{1, 2, 3, 4}},
+		{name: "page", query: func(q *HistoryQuery) *HistoryQuery { return q.Status("FAILURE").Page(1, 5) }, want: []int64{3}},
+		{name: "page past end", query: func(q *HistoryQuery) *HistoryQuery { return q.Page(5, 0) }, want: []int64{}},
+		{name: "negative limit", query: func(q *HistoryQuery) *HistoryQuery { return q.Page(0, -3) }, want: []int64{1, 2, 3, 4}},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := historyIDs(test.query(QueryHistory(history())).Results()); !sameIDs(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                string
+		length, offset, lim int
+		wantStart, wantEnd  int
+	}{
+		{name: "no paging", length: 10, wantStart: 0, wantEnd: 10},
+		{name: "limit", length: 10, lim: 3, wantStart: 0, wantEnd: 3},
+		{name: "offset and limit", length: 10, offset: 4, lim: 3, wantStart: 4, wantEnd: 7},
+		{name: "limit past end", length: 10, offset: 8, lim: 5, wantStart: 8, wantEnd: 10},
+		{name: "offset at end", length: 10, offset: 10, lim: 5, wantStart: 10, wantEnd: 10},
+		{name: "offset past end", length: 10, offset: 20, lim: 5, wantStart: 10, wantEnd: 10},
+		{name: "negative offset", length: 10, offset: -5, lim: 2, wantStart: 0, wantEnd: 2},
+		{name: "negative limit", length: 10, offset: 2, lim: -1, wantStart: 2, wantEnd: 10},
+		{name: "empty", length: 0, offset: 1, lim: 1, wantStart: 0, wantEnd: 0},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if start, end := page(test.length, test.offset, test.lim); start != test.wantStart || end != test.wantEnd {
+				t.Errorf("got [%d:%d], want [%d:%d]", start, end, test.wantStart, test.wantEnd)
+			}
+		})
+	}
+}
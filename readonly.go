@@ -0,0 +1,92 @@
+package nzbget
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+)
+
+// ErrReadOnly is wrapped by ReadOnlyError, so errors.Is(err, ErrReadOnly) works.
+var ErrReadOnly = errors.New("client is read-only")
+
+// ReadOnlyError is returned when a read-only client refuses a mutating method.
+type ReadOnlyError struct {
+	Method string
+}
+
+// Error satisfies the error interface.
+func (e *ReadOnlyError) Error() string {
+	return "refusing " + e.Method + ": " + ErrReadOnly.Error()
+}
+
+// Unwrap returns ErrReadOnly.
+func (e *ReadOnlyError) Unwrap() error {
+	return ErrReadOnly
+}
+
+// mutating are the RPC methods that change NZBGet's state.
+var mutating = map[string]bool{ //nolint:gochecknoglobals
+	"shutdown":          true,
+	"reload":            true,
+	"saveconfig":        true,
+	"editqueue":         true,
+	"append":            true,
+	"scan":              true,
+	"rate":              true,
+	"pausedownload":     true,
+	"resumedownload":    true,
+	"pausepost":         true,
+	"resumepost":        true,
+	"pausescan":         true,
+	"resumescan":        true,
+	"scheduleresume":    true,
+	"writelog":          true,
+	"resetservervolume": true,
+}
+
+// IsMutating returns true if the RPC method changes NZBGet's state.
+// These methods are refused by ReadOnly clients and skipped by DryRun clients.
+func IsMutating(method string) bool {
+	return mutating[method]
+}
+
+// DryRunNZBID is the first synthetic NZBID returned by append on a DryRun client.
+// Each skipped append returns the next ID, so callers see a successful, unique result.
+const DryRunNZBID int64 = 1 << 40
+
+// guardMiddleware refuses (ReadOnly) or skips (DryRun) mutating methods.
+// ReadOnly wins if both are set. Skipped methods returning a bool report true,
+// and skipped appends return a synthetic NZBID counting up from DryRunNZBID.
+func (c *Config) guardMiddleware() Middleware {
+	readOnly := c.ReadOnly
+	nextID := DryRunNZBID - 1
+
+	logger := c.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(ctx context.Context, call *Call) error {
+			if !IsMutating(call.Method) {
+				return next(ctx, call)
+			}
+
+			if readOnly {
+				return &ReadOnlyError{Method: call.Method}
+			}
+
+			logger.Printf("[DRY RUN] nzbget %s %v", call.Method, RedactArgs(call.Args))
+
+			switch output := call.Output.(type) {
+			case *bool:
+				*output = true
+			case *int64:
+				*output = atomic.AddInt64(&nextID, 1)
+			}
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package nzbget
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	client := New(&Config{URL: "http://127.0.0.1:1", DryRun: true, Logger: log.New(&buf, "", 0)})
+	input := &AppendInput{Filename: "a.nzb", Content: strings.Repeat("QUJD", 100)}
+
+	first, err := client.AppendContext(context.Background(), input)
+	if err != nil || first != DryRunNZBID {
+		t.Fatalf("first append: got %d, %v; want %d", first, err, DryRunNZBID)
+	}
+
+	if second, _ := client.AppendContext(context.Background(), input); second != first+1 {
+		t.Errorf("second append: got %d, want %d", second, first+1)
+	}
+
+	if ok, err := client.PauseDownloadContext(context.Background()); !ok || err != nil {
+		t.Errorf("pausedownload: got %v, %v; want true", ok, err)
+	}
+
+	if strings.Contains(buf.String(), input.Content) {
+		t.Error("dry run log contains the NZB content")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+
+	client := New(&Config{URL: "http://127.0.0.1:1", ReadOnly: true, DryRun: true})
+	if _, err := client.ShutdownContext(context.Background()); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly, got: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package sabnzbd
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Category is a SABnzbd category, from an NZBGet category.
+type Category struct {
+	Name     string `json:"name"`
+	Order    int    `json:"order"`
+	PP       string `json:"pp"`
+	Script   string `json:"script"`
+	Dir      string `json:"dir"`
+	Priority int    `json:"priority"`
+}
+
+// getConfig returns the settings tools read from SABnzbd: the completed
+// download directory and the categories, from the NZBGet configuration.
+func (h *Handler) getConfig(req *http.Request) (interface{}, error) {
+	config, categories, err := h.categories(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"config": map[string]interface{}{
+		"misc": map[string]interface{}{
+			"complete_dir":         config["DestDir"],
+			"download_dir":         orString(config["InterDir"], config["DestDir"]),
+			"pre_check":            false,
+			"history_retention":    "",
+			"enable_tv_sorting":    false,
+			"enable_movie_sorting": false,
+			"enable_date_sorting":  false,
+			"api_key":              "", // never send the key back.
+		},
+		"categories": categories,
+		"sorters":    []interface{}{},
+	}}, nil
+}
+
+// getCategories returns the category names.
+func (h *Handler) getCategories(req *http.Request) (interface{}, error) {
+	_, categories, err := h.categories(req)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(categories))
+	for idx, category := range categories {
+		names[idx] = category.Name
+	}
+
+	return map[string]interface{}{"categories": names}, nil
+}
+
+// categories returns the NZBGet configuration, and its categories as SABnzbd categories.
+// The first category is SABnzbd's default category, named *.
+func (h *Handler) categories(req *http.Request) (map[string]string, []*Category, error) {
+	params, err := h.client.ConfigContext(req.Context())
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting config: %w", err)
+	}
+
+	config := make(map[string]string, len(params))
+	for _, param := range params {
+		config[param.Name] = param.Value
+	}
+
+	categories := []*Category{{Name: "*", Script: "Default", Priority: sabPriorityDefault}}
+
+	for idx := 1; config[fmt.Sprintf("Category%d.Name", idx)] != ""; idx++ {
+		name := config[fmt.Sprintf("Category%d.Name", idx)]
+
+		dir := config[fmt.Sprintf("Category%d.DestDir", idx)]
+		if dir == "" && !strings.EqualFold(config["AppendCategoryDir"], "no") {
+			dir = filepath.Join(config["DestDir"], name)
+		}
+
+		categories = append(categories, &Category{
+			Name:     name,
+			Order:    idx,
+			Script:   "Default",
+			Dir:      dir,
+			Priority: sabPriorityDefault,
+		})
+	}
+
+	return config, categories, nil
+}
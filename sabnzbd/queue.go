@@ -0,0 +1,422 @@
+package sabnzbd
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// Queue is the reply for the queue mode.
+type Queue struct {
+	Status          string       `json:"status"`
+	Paused          bool         `json:"paused"`
+	SpeedLimit      string       `json:"speedlimit"`
+	SpeedLimitAbs   string       `json:"speedlimit_abs"`
+	Speed           string       `json:"speed"`
+	KBPerSec        string       `json:"kbpersec"`
+	Size            string       `json:"size"`
+	SizeLeft        string       `json:"sizeleft"`
+	MB              string       `json:"mb"`
+	MBLeft          string       `json:"mbleft"`
+	TimeLeft        string       `json:"timeleft"`
+	DiskSpace       string       `json:"diskspace1"`
+	NoOfSlots       int          `json:"noofslots"`
+	NoOfSlotsTotal  int          `json:"noofslots_total"`
+	Start           int          `json:"start"`
+	Limit           int          `json:"limit"`
+	Version         string       `json:"version"`
+	Slots           []*QueueSlot `json:"slots"`
+	RefreshRate     string       `json:"refresh_rate"`
+	HaveWarnings    string       `json:"have_warnings"`
+	PauseInt        string       `json:"pause_int"`
+	QueueDetails    string       `json:"queue_details"`
+	DownloadedToday string       `json:"day_size"`
+}
+
+// QueueSlot is one item in the queue.
+type QueueSlot struct {
+	Index      int      `json:"index"`
+	NZOID      string   `json:"nzo_id"`
+	Status     string   `json:"status"`
+	Filename   string   `json:"filename"`
+	Category   string   `json:"cat"`
+	Priority   string   `json:"priority"`
+	Labels     []string `json:"labels"`
+	Password   string   `json:"password"`
+	Script     string   `json:"script"`
+	AvgAge     string   `json:"avg_age"`
+	MB         string   `json:"mb"`
+	MBLeft     string   `json:"mbleft"`
+	MBMissing  string   `json:"mbmissing"`
+	Size       string   `json:"size"`
+	SizeLeft   string   `json:"sizeleft"`
+	Percentage string   `json:"percentage"`
+	TimeLeft   string   `json:"timeleft"`
+	UnpackOpts string   `json:"unpackopts"`
+}
+
+// History is the reply for the history mode.
+type History struct {
+	NoOfSlots  int            `json:"noofslots"`
+	PPSlots    int            `json:"ppslots"`
+	DaySize    string         `json:"day_size"`
+	MonthSize  string         `json:"month_size"`
+	TotalSize  string         `json:"total_size"`
+	Slots      []*HistorySlot `json:"slots"`
+	LastUpdate int64          `json:"last_history_update"`
+}
+
+// HistorySlot is one item in the history, or in post-processing.
+type HistorySlot struct {
+	ID           int64    `json:"id"`
+	NZOID        string   `json:"nzo_id"`
+	Name         string   `json:"name"`
+	NZBName      string   `json:"nzb_name"`
+	Category     string   `json:"category"`
+	Status       string   `json:"status"`
+	FailMessage  string   `json:"fail_message"`
+	Storage      string   `json:"storage"`
+	Path         string   `json:"path"`
+	Completed    int64    `json:"completed"`
+	DownloadTime int64    `json:"download_time"`
+	PostProcTime int64    `json:"postproc_time"`
+	Bytes        int64    `json:"bytes"`
+	Downloaded   int64    `json:"downloaded"`
+	Size         string   `json:"size"`
+	URL          string   `json:"url"`
+	DuplicateKey string   `json:"duplicate_key"`
+	Script       string   `json:"script"`
+	ScriptLine   string   `json:"script_line"`
+	ActionLine   string   `json:"action_line"`
+	PP           string   `json:"pp"`
+	Retry        int      `json:"retry"`
+	Loaded       bool     `json:"loaded"`
+	StageLog     []string `json:"stage_log"`
+}
+
+// queue returns the queued downloads, or edits them if name is delete, pause or resume.
+func (h *Handler) queue(req *http.Request) (interface{}, error) {
+	switch req.FormValue("name") {
+	case "delete":
+		return h.delete(req)
+	case "pause":
+		return h.pause(req)
+	case "resume":
+		return h.resume(req)
+	}
+
+	status, err := h.client.StatusContext(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("getting status: %w", err)
+	}
+
+	groups, err := h.client.ListGroupsContext(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("getting queue: %w", err)
+	}
+
+	queued := []*nzbget.Group{}
+
+	for _, group := range groups {
+		if downloading(group.Status) {
+			queued = append(queued, group)
+		}
+	}
+
+	h.estimator.AddSample(status)
+	estimate := h.estimator.Estimate(status, queued)
+
+	return map[string]interface{}{"queue": h.newQueue(req, status, estimate)}, nil
+}
+
+func (h *Handler) newQueue(req *http.Request, status *nzbget.Status, estimate *nzbget.QueueETA) *Queue {
+	queue := &Queue{
+		Status:          StatusDownloading,
+		Paused:          status.DownloadPaused,
+		Speed:           size(status.DownloadRate),
+		KBPerSec:        fmt.Sprintf("%.2f", float64(status.DownloadRate)/kibibyte),
+		SpeedLimit:      "0",
+		SpeedLimitAbs:   strconv.FormatInt(status.DownloadLimit, 10),
+		TimeLeft:        timeLeft(estimate.Remaining),
+		DiskSpace:       fmt.Sprintf("%.2f", float64(status.FreeDiskSpaceMB)/kibibyte),
+		NoOfSlotsTotal:  len(estimate.Items),
+		Version:         h.config.Version,
+		RefreshRate:     "1",
+		HaveWarnings:    "0",
+		PauseInt:        "0",
+		QueueDetails:    "0",
+		DownloadedToday: size(nzbget.JoinSize(status.DaySizeHi, status.DaySizeLo)),
+		Slots:           []*QueueSlot{},
+	}
+
+	switch {
+	case status.DownloadPaused:
+		queue.Status = StatusPaused
+	case !downloadable(estimate):
+		queue.Status = "Idle"
+	}
+
+	var total, left int64
+
+	start, limit := atoi(req.FormValue("start")), atoi(req.FormValue("limit"))
+	category := req.FormValue("cat")
+
+	matched := 0
+
+	for idx, item := range estimate.Items {
+		slot := newQueueSlot(idx, item)
+		total += nzbget.JoinSize(item.Group.FileSizeHi, item.Group.FileSizeLo)
+		left += nzbget.JoinSize(item.Group.RemainingSizeHi, item.Group.RemainingSizeLo)
+
+		if category != "" && category != "*" && !strings.EqualFold(category, slot.Category) {
+			continue
+		}
+
+		if matched++; matched > start && (limit <= 0 || len(queue.Slots) < limit) {
+			queue.Slots = append(queue.Slots, slot)
+		}
+	}
+
+	queue.Size, queue.SizeLeft = size(total), size(left)
+	queue.MB, queue.MBLeft = megabytes(total), megabytes(left)
+	queue.NoOfSlots, queue.Start, queue.Limit = len(queue.Slots), start, limit
+
+	return queue
+}
+
+func newQueueSlot(idx int, item *nzbget.ItemETA) *QueueSlot {
+	group := item.Group
+	total := nzbget.JoinSize(group.FileSizeHi, group.FileSizeLo)
+	left := nzbget.JoinSize(group.RemainingSizeHi, group.RemainingSizeLo)
+	slot := &QueueSlot{
+		Index:      idx,
+		NZOID:      nzoID(group.NZBID),
+		Status:     QueueStatus(group.Status),
+		Filename:   group.NZBName,
+		Category:   group.Category,
+		Priority:   priorityName(group.MaxPriority),
+		Labels:     []string{},
+		Script:     "None",
+		AvgAge:     age(group.MaxPostTime.Time),
+		MB:         megabytes(total),
+		MBLeft:     megabytes(left),
+		MBMissing:  "0.0",
+		Size:       size(total),
+		SizeLeft:   size(left),
+		Percentage: strconv.Itoa(int(group.Progress())),
+		UnpackOpts: "3",
+	}
+
+	if slot.Category == "" {
+		slot.Category = "*"
+	}
+
+	// Like SABnzbd, paused slots and slots without an estimate show 0:00:00.
+	if item.Paused || item.Unknown {
+		slot.TimeLeft = timeLeft(0)
+	} else {
+		slot.TimeLeft = timeLeft(time.Until(item.Finish))
+	}
+
+	return slot
+}
+
+// downloadable returns true if any queued group is not paused, even if it has no estimate yet.
+func downloadable(estimate *nzbget.QueueETA) bool {
+	for _, item := range estimate.Items {
+		if !item.Paused {
+			return true
+		}
+	}
+
+	return false
+}
+
+// history returns the post-processing and history items, or deletes them if name is delete.
+func (h *Handler) history(req *http.Request) (interface{}, error) {
+	if req.FormValue("name") == "delete" {
+		return h.deleteHistory(req)
+	}
+
+	status, err := h.client.StatusContext(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("getting status: %w", err)
+	}
+
+	groups, err := h.client.ListGroupsContext(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("getting queue: %w", err)
+	}
+
+	items, err := h.client.HistoryContext(req.Context(), false)
+	if err != nil {
+		return nil, fmt.Errorf("getting history: %w", err)
+	}
+
+	history := &History{
+		DaySize:   size(nzbget.JoinSize(status.DaySizeHi, status.DaySizeLo)),
+		MonthSize: size(nzbget.JoinSize(status.MonthSizeHi, status.MonthSizeLo)),
+		TotalSize: size(nzbget.JoinSize(status.DownloadedSizeHi, status.DownloadedSizeLo)),
+		Slots:     []*HistorySlot{},
+	}
+
+	slots := []*HistorySlot{}
+
+	for _, group := range groups {
+		if !downloading(group.Status) {
+			slots = append(slots, postProcessSlot(group))
+			history.PPSlots++
+		}
+	}
+
+	for _, item := range items {
+		slots = append(slots, historySlot(item))
+
+		if item.HistoryTime.Unix() > history.LastUpdate {
+			history.LastUpdate = item.HistoryTime.Unix()
+		}
+	}
+
+	start, limit := atoi(req.FormValue("start")), atoi(req.FormValue("limit"))
+	category, failedOnly := req.FormValue("category"), req.FormValue("failed_only") == "1"
+	ids := map[int64]bool{}
+
+	for _, id := range nzbIDs(req.FormValue("nzo_ids")) {
+		ids[id] = true
+	}
+
+	for _, slot := range slots {
+		switch {
+		case category != "" && category != "*" && !strings.EqualFold(category, slot.Category),
+			failedOnly && slot.Status != StatusFailed,
+			len(ids) > 0 && !ids[slot.ID]:
+			continue
+		}
+
+		history.NoOfSlots++
+
+		if history.NoOfSlots > start && (limit <= 0 || len(history.Slots) < limit) {
+			history.Slots = append(history.Slots, slot)
+		}
+	}
+
+	return map[string]interface{}{"history": history}, nil
+}
+
+func postProcessSlot(group *nzbget.Group) *HistorySlot {
+	bytes := nzbget.JoinSize(group.FileSizeHi, group.FileSizeLo)
+
+	return &HistorySlot{
+		ID:           group.NZBID,
+		NZOID:        nzoID(group.NZBID),
+		Name:         group.NZBName,
+		NZBName:      baseName(group.NZBFilename),
+		Category:     orDefault(group.Category),
+		Status:       QueueStatus(group.Status),
+		Storage:      orString(group.FinalDir, group.DestDir),
+		Path:         group.DestDir,
+		DownloadTime: group.DownloadTimeSec,
+		PostProcTime: group.PostTotalTimeSec,
+		Bytes:        bytes,
+		Downloaded:   nzbget.JoinSize(group.DownloadedSizeHi, group.DownloadedSizeLo),
+		Size:         size(bytes),
+		URL:          group.URL,
+		DuplicateKey: group.DupeKey,
+		Script:       "None",
+		ActionLine:   group.PostInfoText,
+		PP:           "D",
+		StageLog:     []string{},
+	}
+}
+
+func historySlot(item *nzbget.History) *HistorySlot {
+	bytes := nzbget.JoinSize(item.FileSizeHi, item.FileSizeLo)
+	slot := &HistorySlot{
+		ID:           item.NZBID,
+		NZOID:        nzoID(item.NZBID),
+		Name:         item.Name,
+		NZBName:      baseName(item.NZBFilename),
+		Category:     orDefault(item.Category),
+		Status:       HistoryStatus(item.Status),
+		Storage:      orString(item.FinalDir, item.DestDir),
+		Path:         item.DestDir,
+		DownloadTime: item.DownloadTimeSec,
+		PostProcTime: item.PostTotalTimeSec,
+		Bytes:        bytes,
+		Downloaded:   nzbget.JoinSize(item.DownloadedSizeHi, item.DownloadedSizeLo),
+		Size:         size(bytes),
+		URL:          item.URL,
+		DuplicateKey: item.DupeKey,
+		Script:       "None",
+		PP:           "D",
+		StageLog:     []string{},
+	}
+
+	if slot.Status == StatusFailed {
+		slot.FailMessage = item.Status
+	}
+
+	if !item.HistoryTime.IsZero() {
+		slot.Completed = item.HistoryTime.Unix()
+	}
+
+	return slot
+}
+
+// deleteHistory deletes the history items in value. Value may also be all, or failed.
+func (h *Handler) deleteHistory(req *http.Request) (interface{}, error) {
+	value := req.FormValue("value")
+	ids := nzbIDs(value)
+
+	if value == "all" || value == "failed" {
+		items, err := h.client.HistoryContext(req.Context(), false)
+		if err != nil {
+			return nil, fmt.Errorf("getting history: %w", err)
+		}
+
+		for _, item := range items {
+			if value == "all" || HistoryStatus(item.Status) == StatusFailed {
+				ids = append(ids, item.NZBID)
+			}
+		}
+	}
+
+	command := "HistoryDelete"
+	if req.FormValue("del_files") == "1" {
+		command = "HistoryFinalDelete"
+	}
+
+	return h.edit(req, command, ids)
+}
+
+// baseName returns the file name from a path, or an empty string for an empty path.
+func baseName(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	return filepath.Base(path)
+}
+
+func atoi(value string) int {
+	val, _ := strconv.Atoi(value)
+	return val
+}
+
+func orString(val, fallback string) string {
+	if val != "" {
+		return val
+	}
+
+	return fallback
+}
+
+// orDefault returns SABnzbd's name for the default category when category is empty.
+func orDefault(category string) string {
+	return orString(category, "*")
+}
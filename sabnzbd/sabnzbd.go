@@ -0,0 +1,311 @@
+// Package sabnzbd provides an http.Handler that implements the parts of SABnzbd's API
+// most download tools use, backed by an NZBGet client. Point tools that only speak
+// SABnzbd at this handler's /api path to use NZBGet instead.
+//
+// Supported modes are queue, history, addurl, addfile, pause, resume, delete,
+// config, get_config, get_cats and version. Responses are always JSON.
+package sabnzbd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golift.io/nzbget"
+)
+
+// DefaultVersion is the SABnzbd version reported by the version mode.
+const DefaultVersion = "4.3.3"
+
+// DefaultMaxUpload is the largest NZB file accepted by addfile.
+const DefaultMaxUpload = 100 << 20
+
+// Errors returned to API callers.
+var (
+	ErrAPIKey      = errors.New("API Key Incorrect")
+	ErrUnknownMode = errors.New("not implemented")
+	ErrMissingArg  = errors.New("expects one parameter")
+)
+
+// Client is the part of *nzbget.NZBGet the Handler uses.
+type Client interface {
+	StatusContext(ctx context.Context) (*nzbget.Status, error)
+	ListGroupsContext(ctx context.Context) ([]*nzbget.Group, error)
+	HistoryContext(ctx context.Context, hidden bool) ([]*nzbget.History, error)
+	ConfigContext(ctx context.Context) ([]*nzbget.Parameter, error)
+	AppendContext(ctx context.Context, input *nzbget.AppendInput) (int64, error)
+	EditQueueContext(ctx context.Context, command, parameter string, ids []int64) (bool, error)
+	PauseDownloadContext(ctx context.Context) (bool, error)
+	ResumeDownloadContext(ctx context.Context) (bool, error)
+	RateContext(ctx context.Context, limit int64) (bool, error)
+}
+
+var _ Client = (*nzbget.NZBGet)(nil)
+
+// Config is the input data needed to return a Handler.
+type Config struct {
+	APIKey    string        // required from callers as apikey, if set.
+	Version   string        // SABnzbd version to report, default: DefaultVersion
+	MaxUpload int64         // default: DefaultMaxUpload
+	DupeMode  string        // duplicate mode for added NZBs: SCORE, ALL or FORCE, default: NZBGet's SCORE
+	Logger    nzbget.Logger // optional, default: log.Default()
+}
+
+// Handler serves SABnzbd API requests from NZBGet.
+type Handler struct {
+	client    Client
+	config    *Config
+	estimator *nzbget.Estimator
+}
+
+// New returns a SABnzbd API handler.
+func New(client Client, config *Config) *Handler {
+	if config == nil {
+		config = &Config{}
+	}
+
+	if config.Version == "" {
+		config.Version = DefaultVersion
+	}
+
+	if config.MaxUpload <= 0 {
+		config.MaxUpload = DefaultMaxUpload
+	}
+
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	return &Handler{client: client, config: config, estimator: nzbget.NewEstimator(0)}
+}
+
+// mode handles one SABnzbd API mode.
+type mode func(h *Handler, req *http.Request) (interface{}, error)
+
+//nolint:gochecknoglobals
+var modes = map[string]mode{
+	"version":    (*Handler).version,
+	"queue":      (*Handler).queue,
+	"history":    (*Handler).history,
+	"addurl":     (*Handler).addURL,
+	"addfile":    (*Handler).addFile,
+	"pause":      (*Handler).pause,
+	"resume":     (*Handler).resume,
+	"delete":     (*Handler).delete,
+	"config":     (*Handler).setConfig,
+	"get_config": (*Handler).getConfig,
+	"get_cats":   (*Handler).getCategories,
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (h *Handler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	var (
+		reply interface{}
+		err   error
+	)
+
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		req.Body = http.MaxBytesReader(resp, req.Body, h.config.MaxUpload)
+		err = req.ParseMultipartForm(h.config.MaxUpload)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("reading upload: %w", err)
+	} else if handler, ok := modes[req.FormValue("mode")]; !ok {
+		err = ErrUnknownMode
+	} else if h.config.APIKey != "" &&
+		subtle.ConstantTimeCompare([]byte(h.config.APIKey), []byte(req.FormValue("apikey"))) != 1 {
+		err = ErrAPIKey
+	} else {
+		reply, err = handler(h, req)
+	}
+
+	if err != nil {
+		reply = map[string]interface{}{"status": false, "error": err.Error()}
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(resp).Encode(reply); err != nil {
+		h.config.Logger.Printf("[ERROR] sabnzbd adapter: writing response: %v", err)
+	}
+}
+
+// status is the reply for modes that only report success.
+type status struct {
+	Status bool     `json:"status"`
+	NZOIDs []string `json:"nzo_ids,omitempty"`
+}
+
+func (h *Handler) version(*http.Request) (interface{}, error) {
+	return map[string]string{"version": h.config.Version}, nil
+}
+
+func (h *Handler) addURL(req *http.Request) (interface{}, error) {
+	url := req.FormValue("name")
+	if url == "" {
+		return nil, fmt.Errorf("name %w", ErrMissingArg)
+	}
+
+	return h.add(req, req.FormValue("nzbname"), url)
+}
+
+func (h *Handler) addFile(req *http.Request) (interface{}, error) {
+	file, header, err := req.FormFile("name")
+	if errors.Is(err, http.ErrMissingFile) {
+		file, header, err = req.FormFile("nzbfile")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("name %w", ErrMissingArg)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading upload: %w", err)
+	}
+
+	name := req.FormValue("nzbname")
+	if name == "" {
+		name = header.Filename
+	}
+
+	return h.add(req, name, base64.StdEncoding.EncodeToString(data))
+}
+
+// add appends an NZB URL or base64 encoded NZB file to the queue.
+func (h *Handler) add(req *http.Request, name, content string) (interface{}, error) {
+	prio, paused := priority(req.FormValue("priority"))
+
+	category := req.FormValue("cat")
+	if category == "*" || category == "Default" {
+		category = ""
+	}
+
+	nzbID, err := h.client.AppendContext(req.Context(), &nzbget.AppendInput{
+		Filename:  name,
+		Content:   content,
+		Category:  category,
+		Priority:  prio,
+		AddPaused: paused,
+		DupeMode:  h.config.DupeMode,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("adding nzb: %w", err)
+	} else if nzbID <= 0 {
+		return &status{Status: false, NZOIDs: []string{}}, nil
+	}
+
+	return &status{Status: true, NZOIDs: []string{nzoID(nzbID)}}, nil
+}
+
+// pause pauses the download queue, or the items in value.
+func (h *Handler) pause(req *http.Request) (interface{}, error) {
+	if req.FormValue("value") != "" {
+		return h.edit(req, "GroupPause", nzbIDs(req.FormValue("value")))
+	}
+
+	_, err := h.client.PauseDownloadContext(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("pausing downloads: %w", err)
+	}
+
+	return &status{Status: true}, nil
+}
+
+// resume resumes the download queue, or the items in value.
+func (h *Handler) resume(req *http.Request) (interface{}, error) {
+	if req.FormValue("value") != "" {
+		return h.edit(req, "GroupResume", nzbIDs(req.FormValue("value")))
+	}
+
+	_, err := h.client.ResumeDownloadContext(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("resuming downloads: %w", err)
+	}
+
+	return &status{Status: true}, nil
+}
+
+// delete deletes the queue items in value, or every queue item if value is all.
+func (h *Handler) delete(req *http.Request) (interface{}, error) {
+	ids := nzbIDs(req.FormValue("value"))
+
+	if req.FormValue("value") == "all" {
+		groups, err := h.client.ListGroupsContext(req.Context())
+		if err != nil {
+			return nil, fmt.Errorf("getting queue: %w", err)
+		}
+
+		for _, group := range groups {
+			if downloading(group.Status) {
+				ids = append(ids, group.NZBID)
+			}
+		}
+	}
+
+	command := "GroupDelete"
+	if req.FormValue("del_files") == "0" {
+		command = "GroupParkDelete"
+	}
+
+	return h.edit(req, command, ids)
+}
+
+// edit runs an edit queue command for ids.
+func (h *Handler) edit(req *http.Request, command string, ids []int64) (interface{}, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("value %w", ErrMissingArg)
+	}
+
+	nzoIDs := make([]string, len(ids))
+	for idx, id := range ids {
+		nzoIDs[idx] = nzoID(id)
+	}
+
+	ok, err := h.client.EditQueueContext(req.Context(), command, "", ids)
+	if err != nil {
+		return nil, fmt.Errorf("editing queue: %w", err)
+	}
+
+	return &status{Status: ok, NZOIDs: nzoIDs}, nil
+}
+
+// setConfig sets the speed limit. Other settings are reported by get_config, and can not be changed.
+// Limits may have a K or M suffix. Plain numbers are KB/s, because NZBGet has no maximum line speed.
+func (h *Handler) setConfig(req *http.Request) (interface{}, error) {
+	switch req.FormValue("name") {
+	case "":
+		return h.getConfig(req)
+	case "speedlimit":
+	default:
+		return nil, ErrUnknownMode
+	}
+
+	value := strings.ToUpper(strings.TrimSpace(req.FormValue("value")))
+	multiplier := 1.0
+
+	if strings.HasSuffix(value, "M") {
+		multiplier = kibibyte
+	}
+
+	limit, err := strconv.ParseFloat(strings.TrimRight(value, "KM"), 64) //nolint:gomnd
+	if err != nil && value != "" {
+		return nil, fmt.Errorf("parsing speed limit: %w", err)
+	}
+
+	if _, err := h.client.RateContext(req.Context(), int64(limit*multiplier)); err != nil {
+		return nil, fmt.Errorf("setting speed limit: %w", err)
+	}
+
+	return &status{Status: true}, nil
+}
@@ -0,0 +1,382 @@
+package sabnzbd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// fakeClient is an NZBGet instance that records what is added to it.
+type fakeClient struct {
+	status  nzbget.Status
+	groups  []*nzbget.Group
+	history []*nzbget.History
+	config  []*nzbget.Parameter
+	appends []*nzbget.AppendInput
+}
+
+func (f *fakeClient) StatusContext(context.Context) (*nzbget.Status, error) {
+	status := f.status
+	return &status, nil
+}
+
+func (f *fakeClient) ListGroupsContext(context.Context) ([]*nzbget.Group, error) {
+	return f.groups, nil
+}
+
+func (f *fakeClient) HistoryContext(context.Context, bool) ([]*nzbget.History, error) {
+	return f.history, nil
+}
+
+func (f *fakeClient) ConfigContext(context.Context) ([]*nzbget.Parameter, error) {
+	return f.config, nil
+}
+
+func (f *fakeClient) AppendContext(_ context.Context, input *nzbget.AppendInput) (int64, error) {
+	f.appends = append(f.appends, input)
+	return int64(len(f.appends)), nil
+}
+
+func (f *fakeClient) EditQueueContext(context.Context, string, string, []int64) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeClient) PauseDownloadContext(context.Context) (bool, error)  { return true, nil }
+func (f *fakeClient) ResumeDownloadContext(context.Context) (bool, error) { return true, nil }
+func (f *fakeClient) RateContext(context.Context, int64) (bool, error)    { return true, nil }
+
+func newHandler(client *fakeClient, config *Config) *Handler {
+	config.APIKey = "key"
+	config.Logger = log.New(io.Discard, "", 0)
+
+	return New(client, config)
+}
+
+// serve sends req to the handler, and decodes the JSON reply into output.
+func serve(t *testing.T, handler http.Handler, req *http.Request, output interface{}) {
+	t.Helper()
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	if resp.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("got content type %q", resp.Header().Get("Content-Type"))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(output); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(values url.Values) *http.Request {
+	values.Set("apikey", "key")
+	return httptest.NewRequest(http.MethodGet, "/api?"+values.Encode(), nil)
+}
+
+// upload returns an addfile request with an NZB file in the form field named field.
+func upload(t *testing.T, field string, data []byte, values url.Values) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	form := multipart.NewWriter(&body)
+
+	file, err := form.CreateFormFile(field, "Some.Show.S01E01.nzb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = file.Write(data)
+	_ = form.Close()
+
+	values.Set("apikey", "key")
+	values.Set("mode", "addfile")
+	req := httptest.NewRequest(http.MethodPost, "/api?"+values.Encode(), &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	return req
+}
+
+func TestAPIKey(t *testing.T) {
+	t.Parallel()
+
+	handler := newHandler(&fakeClient{}, &Config{})
+
+	var reply map[string]interface{}
+
+	serve(t, handler, httptest.NewRequest(http.MethodGet, "/api?mode=version&apikey=wrong", nil), &reply)
+
+	if reply["status"] != false || reply["error"] != ErrAPIKey.Error() {
+		t.Errorf("got %v, want an API key error", reply)
+	}
+}
+
+func TestAddURL(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{}
+	handler := newHandler(client, &Config{})
+
+	var reply status
+
+	serve(t, handler, get(url.Values{"mode": {"addurl"}, "name": {"https://indexer/get/1"},
+		"nzbname": {"Some.Show"}, "cat": {"tv"}, "priority": {"-2"}}), &reply)
+
+	if !reply.Status || len(reply.NZOIDs) != 1 || reply.NZOIDs[0] != "SABnzbd_nzo_1" {
+		t.Fatalf("got %+v", reply)
+	}
+
+	input := client.appends[0]
+	if input.Filename != "Some.Show" || input.Content != "https://indexer/get/1" || input.Category != "tv" ||
+		!input.AddPaused || input.Priority != priorityNormal || input.DupeMode != "" {
+		t.Errorf("got append %+v; the default duplicate mode must be NZBGet's", input)
+	}
+
+	// The duplicate mode is configurable, and * is the default category.
+	client = &fakeClient{}
+	handler = newHandler(client, &Config{DupeMode: "ALL"})
+	serve(t, handler, get(url.Values{"mode": {"addurl"}, "name": {"https://indexer/get/2"}, "cat": {"*"}}), &reply)
+
+	if input = client.appends[0]; input.DupeMode != "ALL" || input.Category != "" {
+		t.Errorf("got append %+v", input)
+	}
+
+	var failed map[string]interface{}
+	if serve(t, handler, get(url.Values{"mode": {"addurl"}}), &failed); failed["status"] != false {
+		t.Errorf("got %v, want an error without a name", failed)
+	}
+}
+
+func TestAddFile(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{}
+	handler := newHandler(client, &Config{MaxUpload: 1024})
+	nzb := []byte(`<?xml version="1.0"?><nzb></nzb>`)
+
+	for _, field := range []string{"name", "nzbfile"} {
+		var reply status
+		if serve(t, handler, upload(t, field, nzb, url.Values{"priority": {"1"}}), &reply); !reply.Status {
+			t.Fatalf("%s: got %+v", field, reply)
+		}
+	}
+
+	for _, input := range client.appends {
+		if input.Filename != "Some.Show.S01E01.nzb" || input.Content != base64.StdEncoding.EncodeToString(nzb) ||
+			input.Priority != priorityHigh {
+			t.Errorf("got append %+v", input)
+		}
+	}
+
+	// A file over MaxUpload is an upload error, not a missing file.
+	var reply map[string]interface{}
+
+	serve(t, handler, upload(t, "name", bytes.Repeat([]byte("x"), 4096), url.Values{}), &reply)
+
+	if msg, _ := reply["error"].(string); reply["status"] != false || !strings.HasPrefix(msg, "reading upload:") {
+		t.Errorf("got %v, want an upload error", reply)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{
+		status: nzbget.Status{DownloadRate: 2048},
+		groups: []*nzbget.Group{
+			{NZBID: 1, NZBName: "One", Category: "tv", Status: nzbget.GroupDOWNLOADING, MaxPriority: priorityForce,
+				FileSizeLo: 3 << 20, RemainingSizeLo: 1 << 20, FileSizeMB: 3, RemainingSizeMB: 1},
+			{NZBID: 2, NZBName: "Two", Status: nzbget.GroupPAUSED, FileSizeLo: 1 << 20, RemainingSizeLo: 1 << 20},
+			{NZBID: 3, NZBName: "Three", Status: nzbget.GroupUNPACKING},
+			{NZBID: 4, NZBName: "Four", Category: "movies", Status: nzbget.GroupQUEUED},
+		},
+	}
+	handler := newHandler(client, &Config{})
+
+	tests := []struct {
+		name   string
+		values url.Values
+		ids    []string
+		total  int
+	}{
+		{name: "all", values: url.Values{}, ids: []string{"SABnzbd_nzo_1", "SABnzbd_nzo_2", "SABnzbd_nzo_4"}, total: 3},
+		{name: "category", values: url.Values{"cat": {"tv"}}, ids: []string{"SABnzbd_nzo_1"}, total: 3},
+		{name: "default category", values: url.Values{"cat": {"*"}}, ids: []string{"SABnzbd_nzo_1", "SABnzbd_nzo_2", "SABnzbd_nzo_4"}, total: 3},
+		{name: "page", values: url.Values{"start": {"1"}, "limit": {"1"}}, ids: []string{"SABnzbd_nzo_2"}, total: 3},
+	}
+
+	for _, test := range tests {
+		test.values.Set("mode", "queue")
+
+		var reply struct{ Queue Queue }
+
+		serve(t, handler, get(test.values), &reply)
+
+		if got := queueIDs(reply.Queue.Slots); !sameStrings(got, test.ids) || reply.Queue.NoOfSlotsTotal != test.total {
+			t.Errorf("%s: got %v of %d, want %v of %d", test.name, got, reply.Queue.NoOfSlotsTotal, test.ids, test.total)
+		}
+	}
+
+	var reply struct{ Queue Queue }
+
+	serve(t, handler, get(url.Values{"mode": {"queue"}}), &reply)
+
+	queue := reply.Queue
+	if queue.Status != StatusDownloading || queue.Speed != "2.0 KB" || queue.MB != "4.00" || queue.MBLeft != "2.00" {
+		t.Errorf("got queue %+v", queue)
+	}
+
+	if slot := queue.Slots[0]; slot.Status != StatusDownloading || slot.Category != "tv" || slot.Percentage != "66" || slot.Priority != "Force" {
+		t.Errorf("got slot %+v", slot)
+	}
+
+	if slot := queue.Slots[1]; slot.Status != StatusPaused || slot.Category != "*" || slot.TimeLeft != "0:00:00" {
+		t.Errorf("got slot %+v", slot)
+	}
+
+	if slot := queue.Slots[2]; slot.Priority != "Normal" || slot.Category != "movies" {
+		t.Errorf("got slot %+v", slot)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	done := nzbget.Time{Time: time.Unix(1700000000, 0)}
+	client := &fakeClient{
+		groups: []*nzbget.Group{{NZBID: 5, NZBName: "Unpacking", Category: "tv", Status: nzbget.GroupUNPACKING}},
+		history: []*nzbget.History{
+			{NZBID: 1, Name: "Good", Category: "tv", Status: "SUCCESS/ALL", DestDir: "/dl/Good", HistoryTime: done},
+			{NZBID: 2, Name: "Broken", Category: "tv", Status: "FAILURE/PAR", HistoryTime: done},
+			{NZBID: 3, Name: "Removed", Category: "movies", Status: "DELETED/MANUAL", HistoryTime: done},
+			{NZBID: 4, Name: "Dupe", Status: "DELETED/DUPE", HistoryTime: done},
+		},
+	}
+	handler := newHandler(client, &Config{})
+
+	tests := []struct {
+		name   string
+		values url.Values
+		ids    []int64
+	}{
+		{name: "all", values: url.Values{}, ids: []int64{5, 1, 2, 3, 4}},
+		{name: "failed only", values: url.Values{"failed_only": {"1"}}, ids: []int64{2}},
+		{name: "category", values: url.Values{"category": {"tv"}}, ids: []int64{5, 1, 2}},
+		{name: "ids", values: url.Values{"nzo_ids": {"SABnzbd_nzo_3,4"}}, ids: []int64{3, 4}},
+		{name: "page", values: url.Values{"start": {"1"}, "limit": {"2"}}, ids: []int64{1, 2}},
+	}
+
+	for _, test := range tests {
+		test.values.Set("mode", "history")
+
+		var reply struct{ History History }
+
+		serve(t, handler, get(test.values), &reply)
+
+		if got := historyIDs(reply.History.Slots); !sameInts(got, test.ids) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.ids)
+		}
+	}
+
+	var reply struct{ History History }
+
+	serve(t, handler, get(url.Values{"mode": {"history"}}), &reply)
+
+	history := reply.History
+	if history.PPSlots != 1 || history.NoOfSlots != 5 || history.LastUpdate != 1700000000 {
+		t.Errorf("got history %+v", history)
+	}
+
+	want := []struct{ status, fail string }{
+		{status: StatusExtracting}, {status: StatusCompleted}, {status: StatusFailed, fail: "FAILURE/PAR"},
+		{status: StatusDeleted}, {status: StatusDeleted},
+	}
+
+	for idx, slot := range history.Slots {
+		if slot.Status != want[idx].status || slot.FailMessage != want[idx].fail {
+			t.Errorf("slot %d: got %q/%q, want %q/%q", slot.ID, slot.Status, slot.FailMessage, want[idx].status, want[idx].fail)
+		}
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{config: []*nzbget.Parameter{
+		{Name: "DestDir", Value: "/downloads"},
+		{Name: "Category1.Name", Value: "tv"},
+		{Name: "Category2.Name", Value: "movies"},
+		{Name: "Category2.DestDir", Value: "/movies"},
+	}}
+	handler := newHandler(client, &Config{})
+
+	var reply struct {
+		Config struct {
+			Misc       map[string]interface{} `json:"misc"`
+			Categories []*Category            `json:"categories"`
+		} `json:"config"`
+	}
+
+	serve(t, handler, get(url.Values{"mode": {"get_config"}}), &reply)
+
+	if misc := reply.Config.Misc; misc["api_key"] != "" || misc["complete_dir"] != "/downloads" || misc["download_dir"] != "/downloads" {
+		t.Errorf("got misc %v; the API key must be blank", misc)
+	}
+
+	dirs := []string{}
+	for _, category := range reply.Config.Categories {
+		dirs = append(dirs, category.Name+"="+category.Dir)
+	}
+
+	if want := []string{"*=", "tv=/downloads/tv", "movies=/movies"}; !sameStrings(dirs, want) {
+		t.Errorf("got categories %v, want %v", dirs, want)
+	}
+}
+
+func queueIDs(slots []*QueueSlot) []string {
+	ids := []string{}
+	for _, slot := range slots {
+		ids = append(ids, slot.NZOID)
+	}
+
+	return ids
+}
+
+func historyIDs(slots []*HistorySlot) []int64 {
+	ids := []int64{}
+	for _, slot := range slots {
+		ids = append(ids, slot.ID)
+	}
+
+	return ids
+}
+
+func sameStrings(got, want []string) bool {
+	return strings.Join(got, ",") == strings.Join(want, ",")
+}
+
+func sameInts(got, want []int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+
+	for idx := range got {
+		if got[idx] != want[idx] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,205 @@
+package sabnzbd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// SABnzbd status strings.
+const (
+	StatusQueued      = "Queued"
+	StatusPaused      = "Paused"
+	StatusDownloading = "Downloading"
+	StatusGrabbing    = "Grabbing"
+	StatusVerifying   = "Verifying"
+	StatusRepairing   = "Repairing"
+	StatusExtracting  = "Extracting"
+	StatusMoving      = "Moving"
+	StatusRunning     = "Running"
+	StatusCompleted   = "Completed"
+	StatusFailed      = "Failed"
+	StatusDeleted     = "Deleted"
+)
+
+// QueueStatus returns the SABnzbd status for an NZBGet group status.
+func QueueStatus(status nzbget.GroupStatus) string {
+	switch status {
+	case nzbget.GroupQUEUED, nzbget.GroupPPQUEUED:
+		return StatusQueued
+	case nzbget.GroupPAUSED:
+		return StatusPaused
+	case nzbget.GroupDOWNLOADING:
+		return StatusDownloading
+	case nzbget.GroupFETCHING:
+		return StatusGrabbing
+	case nzbget.GroupLOADINGPARS, nzbget.GroupVERIFYINGSOURCES, nzbget.GroupVERIFYINGREPAIRED, nzbget.GroupRENAMING:
+		return StatusVerifying
+	case nzbget.GroupREPAIRING:
+		return StatusRepairing
+	case nzbget.GroupUNPACKING:
+		return StatusExtracting
+	case nzbget.GroupMOVING:
+		return StatusMoving
+	case nzbget.GroupEXECUTINGSCRIPT:
+		return StatusRunning
+	case nzbget.GroupPPFINISHED:
+		return StatusCompleted
+	default:
+		return StatusQueued
+	}
+}
+
+// HistoryStatus returns the SABnzbd status for an NZBGet history status, like SUCCESS/ALL.
+// Warnings are failures, except script warnings, because the download itself succeeded.
+// Items a user deleted, or NZBGet deleted as duplicates, are Deleted and not Failed,
+// so tools do not blocklist them. Deletes for bad health or bad files are failures.
+func HistoryStatus(status string) string {
+	switch status {
+	case "WARNING/SCRIPT":
+		return StatusCompleted
+	case "DELETED/MANUAL", "DELETED/DUPE", "DELETED/GOOD", "DELETED/COPY":
+		return StatusDeleted
+	}
+
+	if strings.HasPrefix(status, "SUCCESS/") {
+		return StatusCompleted
+	}
+
+	return StatusFailed
+}
+
+// downloading returns true for groups that SABnzbd would show in the queue.
+// Groups in post-processing are shown in the history, like SABnzbd does.
+func downloading(status nzbget.GroupStatus) bool {
+	switch status { //nolint:exhaustive
+	case nzbget.GroupQUEUED, nzbget.GroupPAUSED, nzbget.GroupDOWNLOADING, nzbget.GroupFETCHING:
+		return true
+	default:
+		return false
+	}
+}
+
+// NZBGet priorities.
+const (
+	priorityLow    = -50
+	priorityNormal = 0
+	priorityHigh   = 50
+	priorityForce  = 900
+)
+
+// SABnzbd priorities.
+const (
+	sabPriorityDefault = -100
+	sabPriorityPaused  = -2
+	sabPriorityLow     = -1
+	sabPriorityHigh    = 1
+	sabPriorityForce   = 2
+)
+
+// priorityName returns the SABnzbd name for an NZBGet priority.
+func priorityName(priority int64) string {
+	switch {
+	case priority >= priorityForce:
+		return "Force"
+	case priority >= priorityHigh:
+		return "High"
+	case priority >= priorityNormal:
+		return "Normal"
+	default:
+		return "Low"
+	}
+}
+
+// priority returns the NZBGet priority for a SABnzbd priority, and true if the item should be added paused.
+func priority(value string) (int64, bool) {
+	sab, _ := strconv.Atoi(value)
+
+	switch sab {
+	case sabPriorityPaused:
+		return priorityNormal, true
+	case sabPriorityLow:
+		return priorityLow, false
+	case sabPriorityHigh:
+		return priorityHigh, false
+	case sabPriorityForce:
+		return priorityForce, false
+	default:
+		return priorityNormal, false
+	}
+}
+
+// nzoID returns the SABnzbd ID for an NZBID.
+func nzoID(nzbID int64) string {
+	return "SABnzbd_nzo_" + strconv.FormatInt(nzbID, 10)
+}
+
+// nzbIDs parses a comma separated list of SABnzbd IDs, or plain NZBIDs.
+func nzbIDs(value string) []int64 {
+	ids := []int64{}
+
+	for _, val := range strings.Split(value, ",") {
+		id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(val), "SABnzbd_nzo_"), 10, 64) //nolint:gomnd
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// megabytes formats bytes as megabytes, like SABnzbd's mb fields.
+func megabytes(bytes int64) string {
+	return fmt.Sprintf("%.2f", float64(bytes)/mebibyte)
+}
+
+const (
+	kibibyte = 1024
+	mebibyte = kibibyte * kibibyte
+)
+
+// size formats bytes for display, like 1.2 GB.
+func size(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	val := float64(bytes)
+	unit := 0
+
+	for val >= kibibyte && unit < len(units)-1 {
+		val /= kibibyte
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	return fmt.Sprintf("%.1f %s", val, units[unit])
+}
+
+// timeLeft formats a duration as H:MM:SS.
+func timeLeft(dur time.Duration) string {
+	if dur < 0 {
+		dur = 0
+	}
+
+	secs := int64(dur.Seconds())
+
+	return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60) //nolint:gomnd
+}
+
+// age formats the age of a post, like 3d or 5h.
+func age(posted time.Time) string {
+	switch since := time.Since(posted); {
+	case posted.IsZero() || posted.Unix() == 0:
+		return ""
+	case since >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(since.Hours()/24)) //nolint:gomnd
+	case since >= time.Hour:
+		return fmt.Sprintf("%dh", int(since.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(since.Minutes()))
+	}
+}
@@ -0,0 +1,66 @@
+package sabnzbd
+
+import (
+	"testing"
+
+	"golift.io/nzbget"
+)
+
+func TestQueueStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := map[nzbget.GroupStatus]string{
+		nzbget.GroupQUEUED:            StatusQueued,
+		nzbget.GroupPPQUEUED:          StatusQueued,
+		nzbget.GroupPAUSED:            StatusPaused,
+		nzbget.GroupDOWNLOADING:       StatusDownloading,
+		nzbget.GroupFETCHING:          StatusGrabbing,
+		nzbget.GroupLOADINGPARS:       StatusVerifying,
+		nzbget.GroupVERIFYINGSOURCES:  StatusVerifying,
+		nzbget.GroupVERIFYINGREPAIRED: StatusVerifying,
+		nzbget.GroupRENAMING:          StatusVerifying,
+		nzbget.GroupREPAIRING:         StatusRepairing,
+		nzbget.GroupUNPACKING:         StatusExtracting,
+		nzbget.GroupMOVING:            StatusMoving,
+		nzbget.GroupEXECUTINGSCRIPT:   StatusRunning,
+		nzbget.GroupPPFINISHED:        StatusCompleted,
+		"SOMETHING_NEW":               StatusQueued,
+	}
+
+	for status, want := range tests {
+		if got := QueueStatus(status); got != want {
+			t.Errorf("%s: got %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestHistoryStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"SUCCESS/ALL":     StatusCompleted,
+		"SUCCESS/UNPACK":  StatusCompleted,
+		"SUCCESS/HIDDEN":  StatusCompleted,
+		"WARNING/SCRIPT":  StatusCompleted,
+		"WARNING/DAMAGED": StatusFailed,
+		"WARNING/SPACE":   StatusFailed,
+		"FAILURE/PAR":     StatusFailed,
+		"FAILURE/UNPACK":  StatusFailed,
+		"FAILURE/HEALTH":  StatusFailed,
+		"DELETED/MANUAL":  StatusDeleted,
+		"DELETED/DUPE":    StatusDeleted,
+		"DELETED/GOOD":    StatusDeleted,
+		"DELETED/COPY":    StatusDeleted,
+		"DELETED/HEALTH":  StatusFailed,
+		"DELETED/BAD":     StatusFailed,
+		"DELETED/SCAN":    StatusFailed,
+		"SUCCESS":         StatusFailed,
+		"":                StatusFailed,
+	}
+
+	for status, want := range tests {
+		if got := HistoryStatus(status); got != want {
+			t.Errorf("%q: got %q, want %q", status, got, want)
+		}
+	}
+}
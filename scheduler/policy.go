@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/internal/list"
+)
+
+// ErrInvalidWindow is returned when a Window has an invalid start or end time.
+var ErrInvalidWindow = errors.New("invalid window")
+
+// minutesPerDay is used to wrap window times past midnight.
+const minutesPerDay = 24 * 60
+
+// Policy describes when downloads are throttled or paused.
+// This is setup to allow you to easily pass this data in from a config file.
+//
+//nolint:lll
+type Policy struct {
+	Windows     []*Window      `json:"windows"     toml:"windows"      xml:"window"       yaml:"windows"`     // first matching window wins.
+	DefaultRate int64          `json:"defaultRate" toml:"default_rate" xml:"default_rate" yaml:"defaultRate"` // KB/s outside of windows, 0 is unlimited.
+	QuotaMB     int64          `json:"quotaMb"     toml:"quota_mb"     xml:"quota_mb"     yaml:"quotaMb"`     // monthly download quota, 0 is unlimited.
+	QuotaDay    int            `json:"quotaDay"    toml:"quota_day"    xml:"quota_day"    yaml:"quotaDay"`    // day of the month the quota resets, 1-31.
+	Location    *time.Location `json:"-"           toml:"-"            xml:"-"            yaml:"-"`           // time zone for windows, default: time.Local.
+}
+
+// Window is a weekly time range with its own speed limit, or a pause.
+// Windows that end before they start finish the next day.
+//
+//nolint:lll
+type Window struct {
+	Days  []time.Weekday `json:"days"  toml:"days"  xml:"day"   yaml:"days"`  // days the window starts on, empty is every day.
+	Start string         `json:"start" toml:"start" xml:"start" yaml:"start"` // like 22:00.
+	End   string         `json:"end"   toml:"end"   xml:"end"   yaml:"end"`   // like 06:30.
+	Rate  int64          `json:"rate"  toml:"rate"  xml:"rate"  yaml:"rate"`  // KB/s during the window, 0 is unlimited.
+	Pause bool           `json:"pause" toml:"pause" xml:"pause" yaml:"pause"` // pause downloads during the window.
+}
+
+// Decision is the state the Policy wants NZBGet in at a point in time.
+type Decision struct {
+	Rate   int64     // KB/s, 0 is unlimited.
+	Pause  bool      // downloads should be paused.
+	Until  time.Time // when this decision changes, if known.
+	Reason string    // why the decision was made.
+}
+
+// validate checks the window times, so New can reject a Policy that Decide would partly ignore.
+func (p *Policy) validate() error {
+	for idx, window := range p.Windows {
+		if _, err := parseClock(window.Start); err != nil {
+			return fmt.Errorf("%w %d start: %v", ErrInvalidWindow, idx, err) //nolint:errorlint
+		}
+
+		if _, err := parseClock(window.End); err != nil {
+			return fmt.Errorf("%w %d end: %v", ErrInvalidWindow, idx, err) //nolint:errorlint
+		}
+	}
+
+	return nil
+}
+
+// Decide returns what the policy wants at a point in time, given the quota used this period.
+// An exceeded quota pauses downloads until the next period, and overrides the windows.
+// Windows with invalid times never match.
+func (p *Policy) Decide(now time.Time, usedMB int64) *Decision {
+	now = now.In(p.location())
+
+	if p.QuotaMB > 0 && usedMB >= p.QuotaMB {
+		return &Decision{
+			Pause:  true,
+			Until:  p.PeriodEnd(now),
+			Reason: fmt.Sprintf("quota reached: %d/%d MB", usedMB, p.QuotaMB),
+		}
+	}
+
+	for idx, window := range p.Windows {
+		if until, ok := window.active(now); ok {
+			return &Decision{Rate: window.Rate, Pause: window.Pause, Until: until, Reason: fmt.Sprintf("window %d", idx)}
+		}
+	}
+
+	return &Decision{Rate: p.DefaultRate, Reason: "default"}
+}
+
+// PeriodStart returns the start of the quota period that contains now.
+func (p *Policy) PeriodStart(now time.Time) time.Time {
+	start, _ := nzbget.BillingPeriod(now.In(p.location()), p.quotaDay())
+	return start
+}
+
+// PeriodEnd returns the start of the quota period after the one that contains now.
+func (p *Policy) PeriodEnd(now time.Time) time.Time {
+	_, end := nzbget.BillingPeriod(now.In(p.location()), p.quotaDay())
+	return end
+}
+
+func (p *Policy) location() *time.Location {
+	if p.Location == nil {
+		return time.Local
+	}
+
+	return p.Location
+}
+
+// quotaDay returns QuotaDay, or 1 if it is not a day of the month.
+func (p *Policy) quotaDay() int {
+	if p.QuotaDay < 1 || p.QuotaDay > 31 { //nolint:gomnd
+		return 1
+	}
+
+	return p.QuotaDay
+}
+
+// active returns true and the end time if the window contains now.
+// Windows that started yesterday and wrap past midnight are included.
+// A window with an invalid start or end time is never active.
+func (w *Window) active(now time.Time) (time.Time, bool) {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	end, err := parseClock(w.End)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	minute := now.Hour()*60 + now.Minute() //nolint:gomnd
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	length := end - start
+
+	if length <= 0 {
+		length += minutesPerDay
+	}
+
+	if w.onDay(now.Weekday()) && minute >= start && minute < start+length {
+		return today.Add(time.Duration(start+length) * time.Minute), true
+	}
+
+	yesterday := today.AddDate(0, 0, -1)
+	if w.onDay(yesterday.Weekday()) && minute+minutesPerDay < start+length {
+		return yesterday.Add(time.Duration(start+length) * time.Minute), true
+	}
+
+	return time.Time{}, false
+}
+
+func (w *Window) onDay(day time.Weekday) bool {
+	return len(w.Days) == 0 || list.Contains(w.Days, day)
+}
+
+// parseClock turns a time like 22:30 into minutes after midnight.
+func parseClock(clock string) (int, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return parsed.Hour()*60 + parsed.Minute(), nil //nolint:gomnd
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecideZeroPolicy(t *testing.T) {
+	t.Parallel()
+
+	var policy Policy
+
+	decision := policy.Decide(time.Now(), 1000)
+	if decision.Rate != 0 || decision.Pause || decision.Reason != "default" {
+		t.Errorf("unexpected decision: %+v", decision)
+	}
+
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.Local)
+	if start := policy.PeriodStart(now); !start.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("got period start %v, want March 1", start)
+	}
+
+	if end := policy.PeriodEnd(now); !end.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("got period end %v, want April 1", end)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	t.Parallel()
+
+	utc := time.UTC
+	// A Policy built directly, without New, so the windows are never validated.
+	policy := &Policy{
+		Location:    utc,
+		DefaultRate: 1000,
+		QuotaMB:     500,
+		QuotaDay:    31,
+		Windows: []*Window{
+			{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "06:00", Rate: 100},
+			{Start: "bad", End: "07:00", Pause: true},
+			{Start: "12:00", End: "13:00", Pause: true},
+		},
+	}
+
+	friday := time.Date(2024, 2, 2, 0, 0, 0, 0, utc) // Friday, February 2nd.
+	tests := []struct {
+		name string
+		now  time.Time
+		used int64
+		want Decision
+	}{
+		{name: "default", now: friday.Add(9 * time.Hour), want: Decision{Rate: 1000, Reason: "default"}},
+		{name: "window start", now: friday.Add(22 * time.Hour),
+			want: Decision{Rate: 100, Until: friday.Add(30 * time.Hour), Reason: "window 0"}},
+		{name: "wrapped past midnight", now: friday.Add(29 * time.Hour),
+			want: Decision{Rate: 100, Until: friday.Add(30 * time.Hour), Reason: "window 0"}},
+		{name: "window end", now: friday.Add(30 * time.Hour), want: Decision{Rate: 1000, Reason: "default"}},
+		{name: "other day", now: friday.Add(-2 * time.Hour), want: Decision{Rate: 1000, Reason: "default"}},
+		{name: "invalid window skipped", now: friday.Add(6 * time.Hour), want: Decision{Rate: 1000, Reason: "default"}},
+		{name: "pause window", now: friday.Add(12*time.Hour + 30*time.Minute),
+			want: Decision{Pause: true, Until: friday.Add(13 * time.Hour), Reason: "window 2"}},
+		{name: "quota", now: friday.Add(22 * time.Hour), used: 500,
+			want: Decision{Pause: true, Until: time.Date(2024, 2, 29, 0, 0, 0, 0, utc), Reason: "quota reached: 500/500 MB"}},
+		{name: "location", now: time.Date(2024, 2, 2, 17, 30, 0, 0, time.FixedZone("EST", -5*3600)),
+			want: Decision{Rate: 100, Until: friday.Add(30 * time.Hour), Reason: "window 0"}},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := policy.Decide(test.now, test.used)
+			if got.Rate != test.want.Rate || got.Pause != test.want.Pause ||
+				!got.Until.Equal(test.want.Until) || got.Reason != test.want.Reason {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidWindow(t *testing.T) {
+	t.Parallel()
+
+	config := &Config{Policy: &Policy{Windows: []*Window{{Start: "25:00", End: "06:00"}}}}
+	if _, err := New(&fakeClient{}, config); !errors.Is(err, ErrInvalidWindow) {
+		t.Fatalf("expected ErrInvalidWindow, got: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+// Package scheduler drives NZBGet's download rate and pause state from a weekly policy
+// of speed limits and pause windows, and a monthly download quota.
+// It only changes NZBGet at decision boundaries, when the policy's decision changes, so
+// changes made by a user are left alone until the next boundary. It persists its last
+// decision, so a restart is not a boundary.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/internal/poll"
+	"golift.io/nzbget/internal/statefile"
+)
+
+// DefaultInterval is how often the Scheduler reconciles NZBGet with the Policy.
+const DefaultInterval = time.Minute
+
+// Client is the part of *nzbget.NZBGet the Scheduler uses.
+type Client interface {
+	StatusContext(ctx context.Context) (*nzbget.Status, error)
+	ServerVolumesContext(ctx context.Context) ([]*nzbget.ServerVolume, error)
+	RateContext(ctx context.Context, limit int64) (bool, error)
+	PauseDownloadContext(ctx context.Context) (bool, error)
+	ResumeDownloadContext(ctx context.Context) (bool, error)
+	ScheduleResumeContext(ctx context.Context, wait time.Duration) (bool, error)
+}
+
+// Config is the input data needed to return a Scheduler.
+type Config struct {
+	Policy    *Policy
+	StateFile string        // optional, persists State between restarts.
+	Interval  time.Duration // default: DefaultInterval
+	Logger    nzbget.Logger // optional, default: log.Default()
+}
+
+// State is what the Scheduler last applied to NZBGet. It is saved to the StateFile.
+// Rate, Pause and Until identify the last Decision; when a new Decision differs, the
+// Scheduler has reached a decision boundary and applies it.
+type State struct {
+	Rate    int64     `json:"rate"`    // KB/s, from the last Decision.
+	Pause   bool      `json:"pause"`   // from the last Decision.
+	Until   time.Time `json:"until"`   // from the last Decision.
+	Paused  bool      `json:"paused"`  // true if the scheduler paused downloads.
+	Reason  string    `json:"reason"`  // from the Decision.
+	Updated time.Time `json:"updated"` // last time the state changed.
+}
+
+// Scheduler applies a Policy to NZBGet.
+type Scheduler struct {
+	client Client
+	config *Config
+	mu     sync.Mutex
+	state  State
+}
+
+// New returns a Scheduler, and loads its state from the state file if it exists.
+func New(client Client, config *Config) (*Scheduler, error) {
+	if config.Policy == nil {
+		config.Policy = &Policy{}
+	}
+
+	if err := config.Policy.validate(); err != nil {
+		return nil, err
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	sched := &Scheduler{client: client, config: config}
+
+	return sched, sched.load()
+}
+
+// Run reconciles NZBGet with the Policy immediately, and then every Interval until the context ends.
+// Errors are logged, and do not stop the scheduler.
+func (s *Scheduler) Run(ctx context.Context) {
+	poll.Run(ctx, s.config.Interval, s.config.Logger, "scheduler", s.Reconcile)
+}
+
+// State returns what the Scheduler last applied to NZBGet.
+func (s *Scheduler) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state
+}
+
+// Reconcile compares NZBGet's state with the Policy and changes the rate or pause state as needed.
+// The rate and pause state are only applied at a decision boundary: the first Reconcile without
+// a saved State, or when the Decision changes. Between boundaries, a rate set or downloads
+// resumed by someone else are left alone. Downloads paused by someone else are always left
+// paused, and only pauses made by the scheduler are resumed.
+func (s *Scheduler) Reconcile(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, err := s.client.StatusContext(ctx)
+	if err != nil {
+		return fmt.Errorf("getting status: %w", err)
+	}
+
+	now := time.Now()
+
+	usedMB, err := s.usedMB(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	decision := s.config.Policy.Decide(now, usedMB)
+	state := s.state
+	boundary := state.Updated.IsZero() || decision.Rate != state.Rate ||
+		decision.Pause != state.Pause || !decision.Until.Equal(state.Until)
+
+	if boundary && decision.Rate*1024 != status.DownloadLimit { //nolint:gomnd
+		if _, err := s.client.RateContext(ctx, decision.Rate); err != nil {
+			return fmt.Errorf("setting rate: %w", err)
+		}
+
+		s.config.Logger.Printf("scheduler: set download rate to %d KB/s (%s)", decision.Rate, decision.Reason)
+	}
+
+	state.Rate, state.Pause, state.Until = decision.Rate, decision.Pause, decision.Until
+
+	switch {
+	case boundary && decision.Pause && !status.DownloadPaused:
+		if err := s.pause(ctx, now, decision); err != nil {
+			return err
+		}
+
+		state.Paused = true
+	case !decision.Pause && status.DownloadPaused && state.Paused:
+		if _, err := s.client.ResumeDownloadContext(ctx); err != nil {
+			return fmt.Errorf("resuming downloads: %w", err)
+		}
+
+		s.config.Logger.Printf("scheduler: resumed downloads (%s)", decision.Reason)
+
+		state.Paused = false
+	case !status.DownloadPaused:
+		state.Paused = false // someone else resumed.
+	}
+
+	return s.save(state, decision.Reason, now)
+}
+
+// pause pauses downloads, and asks NZBGet to resume them on its own when the decision expires.
+func (s *Scheduler) pause(ctx context.Context, now time.Time, decision *Decision) error {
+	if _, err := s.client.PauseDownloadContext(ctx); err != nil {
+		return fmt.Errorf("pausing downloads: %w", err)
+	}
+
+	s.config.Logger.Printf("scheduler: paused downloads (%s)", decision.Reason)
+
+	if decision.Until.IsZero() {
+		return nil
+	}
+
+	if _, err := s.client.ScheduleResumeContext(ctx, decision.Until.Sub(now)); err != nil {
+		return fmt.Errorf("scheduling resume: %w", err)
+	}
+
+	return nil
+}
+
+// usedMB returns the megabytes downloaded by all servers since the start of the quota period.
+func (s *Scheduler) usedMB(ctx context.Context, now time.Time) (int64, error) {
+	if s.config.Policy.QuotaMB <= 0 {
+		return 0, nil
+	}
+
+	volumes, err := s.client.ServerVolumesContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("getting server volumes: %w", err)
+	}
+
+	if len(volumes) == 0 {
+		return 0, nil
+	}
+
+	// The first record (serverid=0) are totals for all servers.
+	used := volumes[0].Usage(s.config.Policy.PeriodStart(now), now)
+
+	return used / 1024 / 1024, nil //nolint:gomnd
+}
+
+// load reads the state file, if one is configured and exists.
+func (s *Scheduler) load() error {
+	if s.config.StateFile == "" {
+		return nil
+	}
+
+	return statefile.Load(s.config.StateFile, &s.state) //nolint:wrapcheck
+}
+
+// save stores the new state, and writes it to the state file if it changed.
+func (s *Scheduler) save(state State, reason string, now time.Time) error {
+	state.Reason = reason
+	if state == s.state {
+		return nil
+	}
+
+	state.Updated = now
+	s.state = state
+
+	if s.config.StateFile == "" {
+		return nil
+	}
+
+	return statefile.Save(s.config.StateFile, state) //nolint:wrapcheck
+}
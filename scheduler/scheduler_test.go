@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// fakeClient is an NZBGet with a download limit and pause state, and no quota.
+type fakeClient struct {
+	status nzbget.Status
+	rates  int
+}
+
+func (f *fakeClient) StatusContext(context.Context) (*nzbget.Status, error) {
+	status := f.status
+	return &status, nil
+}
+
+func (f *fakeClient) ServerVolumesContext(context.Context) ([]*nzbget.ServerVolume, error) {
+	return nil, nil
+}
+
+func (f *fakeClient) RateContext(_ context.Context, limit int64) (bool, error) {
+	f.rates++
+	f.status.DownloadLimit = limit * 1024
+
+	return true, nil
+}
+
+func (f *fakeClient) PauseDownloadContext(context.Context) (bool, error) {
+	f.status.DownloadPaused = true
+	return true, nil
+}
+
+func (f *fakeClient) ResumeDownloadContext(context.Context) (bool, error) {
+	f.status.DownloadPaused = false
+	return true, nil
+}
+
+func (f *fakeClient) ScheduleResumeContext(context.Context, time.Duration) (bool, error) {
+	return true, nil
+}
+
+func TestReconcileLeavesUserChanges(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{}
+	policy := &Policy{DefaultRate: 500, Windows: []*Window{{Start: "00:00", End: "00:00", Pause: true}}}
+
+	sched, err := New(client, &Config{Policy: policy, Logger: log.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first reconcile is a decision boundary: the window pauses downloads.
+	if err := sched.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !client.status.DownloadPaused || !sched.State().Paused {
+		t.Fatalf("expected downloads paused by the scheduler, got: %+v", sched.State())
+	}
+
+	// A user resumes downloads and sets a rate. The decision has not changed, so both stay.
+	client.status.DownloadPaused = false
+	client.status.DownloadLimit = 100 * 1024
+	rates := client.rates
+
+	if err := sched.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.status.DownloadPaused || client.rates != rates || sched.State().Paused {
+		t.Fatalf("scheduler overrode user changes: %+v", sched.State())
+	}
+}
+
+func TestReconcileBoundary(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{}
+
+	sched, err := New(client, &Config{Policy: &Policy{DefaultRate: 500}, Logger: log.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sched.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.status.DownloadLimit != 500*1024 {
+		t.Fatalf("got download limit %d, want %d", client.status.DownloadLimit, 500*1024)
+	}
+
+	// The policy changes, so the next reconcile is a boundary and applies the new rate.
+	sched.config.Policy.DefaultRate = 200
+
+	if err := sched.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if client.status.DownloadLimit != 200*1024 || sched.State().Rate != 200 {
+		t.Fatalf("got download limit %d, want %d", client.status.DownloadLimit, 200*1024)
+	}
+}
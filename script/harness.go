@@ -0,0 +1,98 @@
+package script
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// Harness simulates NZBGet running a script, for unit tests. It provides
+// the environment NZBGet would pass, and captures what the script prints.
+type Harness struct {
+	Env Env
+	buf bytes.Buffer
+}
+
+// LogLine is a log message printed by a script.
+type LogLine struct {
+	Kind string // INFO, WARNING, ERROR or DETAIL.
+	Text string
+}
+
+// NewHarness returns a Harness with the provided environment.
+// Use the Environ method of PostProcess, Queue or Scan to build one.
+// Example:
+//
+//	harness := script.NewHarness((&script.Queue{Event: script.EventNZBAdded, Name: "x"}).Environ())
+//	code := harness.Run(run)
+func NewHarness(env Env) *Harness {
+	return &Harness{Env: env}
+}
+
+// Output returns an Output that writes to the harness.
+func (h *Harness) Output() *Output {
+	return NewOutput(&h.buf)
+}
+
+// Run calls a script's run function with the harness environment and output, and returns its exit code.
+func (h *Harness) Run(run func(env Env, out *Output) ExitCode) ExitCode {
+	return run(h.Env, h.Output())
+}
+
+// Stdout returns everything the script printed.
+func (h *Harness) Stdout() string {
+	return h.buf.String()
+}
+
+// Commands returns the [NZB] commands the script printed. Later commands replace earlier ones.
+func (h *Harness) Commands() map[string]string {
+	output := make(map[string]string)
+
+	h.scan(func(line string) {
+		if command, ok := cutPrefix(line, "[NZB] "); ok {
+			if key, val, ok := strings.Cut(command, "="); ok {
+				output[key] = val
+			}
+		}
+	})
+
+	return output
+}
+
+// Logs returns the log messages the script printed, in order.
+// Lines without a log kind prefix are INFO messages, as NZBGet treats them.
+func (h *Harness) Logs() []*LogLine {
+	output := []*LogLine{}
+
+	h.scan(func(line string) {
+		if strings.HasPrefix(line, "[NZB] ") {
+			return
+		}
+
+		for _, kind := range []string{"INFO", "WARNING", "ERROR", "DETAIL"} {
+			if text, ok := cutPrefix(line, "["+kind+"] "); ok {
+				output = append(output, &LogLine{Kind: kind, Text: text})
+				return
+			}
+		}
+
+		output = append(output, &LogLine{Kind: "INFO", Text: line})
+	})
+
+	return output
+}
+
+func (h *Harness) scan(each func(line string)) {
+	scanner := bufio.NewScanner(bytes.NewReader(h.buf.Bytes()))
+	for scanner.Scan() {
+		each(scanner.Text())
+	}
+}
+
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+
+	return strings.TrimPrefix(s, prefix), true
+}
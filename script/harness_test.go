@@ -0,0 +1,117 @@
+package script
+
+import (
+	"reflect"
+	"testing"
+)
+
+// run is a post-processing script that moves a download, for testing the Harness.
+func run(env Env, out *Output) ExitCode {
+	post, err := ParsePostProcess(env)
+	if err != nil {
+		out.Error("%v", err)
+		return ExitError
+	}
+
+	if post.Failed() {
+		out.Warning("skipping failed download %s", post.Name)
+		return ExitNone
+	}
+
+	out.Info("moving %s", post.Name)
+	out.Directory("/tmp/" + post.Name)
+	out.Directory("/final/" + post.Name)
+	out.Parameter("Moved", "yes")
+	out.Detail("done")
+
+	return ExitSuccess
+}
+
+func TestHarness(t *testing.T) {
+	t.Parallel()
+
+	harness := NewHarness((&PostProcess{Name: "Show", Directory: "/downloads/Show", TotalStatus: "SUCCESS"}).Environ())
+
+	if code := harness.Run(run); code != ExitSuccess {
+		t.Errorf("got exit code %d, want %d", code, ExitSuccess)
+	}
+
+	// The later DIRECTORY command replaces the earlier one.
+	wantCommands := map[string]string{"DIRECTORY": "/final/Show", "NZBPR_Moved": "yes"}
+	if commands := harness.Commands(); !reflect.DeepEqual(commands, wantCommands) {
+		t.Errorf("got commands %v, want %v", commands, wantCommands)
+	}
+
+	wantLogs := []*LogLine{{Kind: "INFO", Text: "moving Show"}, {Kind: "DETAIL", Text: "done"}}
+	if logs := harness.Logs(); !reflect.DeepEqual(logs, wantLogs) {
+		t.Errorf("got logs %+v, want %+v", logs, wantLogs)
+	}
+}
+
+func TestHarnessExitCodes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		env  Env
+		code ExitCode
+		logs []*LogLine
+	}{
+		{
+			name: "failed download",
+			env:  (&PostProcess{Name: "Show", Directory: "/downloads/Show", TotalStatus: "FAILURE"}).Environ(),
+			code: ExitNone,
+			logs: []*LogLine{{Kind: "WARNING", Text: "skipping failed download Show"}},
+		},
+		{
+			name: "wrong script type",
+			env:  (&Scan{Filename: "/nzb/a.nzb"}).Environ(),
+			code: ExitError,
+			logs: []*LogLine{{Kind: "ERROR", Text: ErrNotPostProcess.Error()}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			harness := NewHarness(test.env)
+			if code := harness.Run(run); code != test.code {
+				t.Errorf("got exit code %d, want %d", code, test.code)
+			}
+
+			if logs := harness.Logs(); !reflect.DeepEqual(logs, test.logs) {
+				t.Errorf("got logs %+v, want %+v", logs, test.logs)
+			}
+
+			if commands := harness.Commands(); len(commands) != 0 {
+				t.Errorf("got commands %v, want none", commands)
+			}
+		})
+	}
+}
+
+func TestHarnessPlainLines(t *testing.T) {
+	t.Parallel()
+
+	harness := NewHarness(Env{})
+	harness.Run(func(_ Env, out *Output) ExitCode {
+		_, _ = out.w.Write([]byte("plain text\n[NZB] MARK=BAD\n[NZB] no equals sign\n[UNKNOWN] kind\n"))
+		return ExitSuccess
+	})
+
+	// Lines without a known log kind are INFO messages, like NZBGet reads them.
+	wantLogs := []*LogLine{{Kind: "INFO", Text: "plain text"}, {Kind: "INFO", Text: "[UNKNOWN] kind"}}
+	if logs := harness.Logs(); !reflect.DeepEqual(logs, wantLogs) {
+		t.Errorf("got logs %+v, want %+v", logs, wantLogs)
+	}
+
+	if commands := harness.Commands(); !reflect.DeepEqual(commands, map[string]string{"MARK": "BAD"}) {
+		t.Errorf("got commands %v", commands)
+	}
+
+	if stdout := harness.Stdout(); stdout != "plain text\n[NZB] MARK=BAD\n[NZB] no equals sign\n[UNKNOWN] kind\n" {
+		t.Errorf("got stdout %q", stdout)
+	}
+}
@@ -0,0 +1,176 @@
+package script
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golift.io/nzbget"
+)
+
+// ErrNotPostProcess is returned when the environment is not from a post-processing script run.
+var ErrNotPostProcess = errors.New("not running as a post-processing script: NZBPP_DIRECTORY is missing")
+
+// PostProcess is the context NZBGet passes to post-processing scripts in NZBPP_ variables.
+type PostProcess struct {
+	NZBID           int64
+	Name            string // NZBPP_NZBNAME
+	NZBFilename     string
+	Directory       string // destination directory of the download.
+	FinalDir        string // set by another script with the FINALDIR command.
+	Category        string
+	URL             string
+	DupeKey         string
+	DupeScore       int64
+	DupeMode        string
+	TotalStatus     string              // SUCCESS, WARNING, FAILURE or DELETED.
+	Status          string              // like FAILURE/PAR; the same as History.Status.
+	ScriptStatus    nzbget.ScriptStatus // summary status of the scripts that already ran.
+	ParStatus       nzbget.ParStatus
+	UnpackStatus    nzbget.UnpackStatus
+	Health          int64 // per mille.
+	CriticalHealth  int64 // per mille.
+	TotalArticles   int64
+	SuccessArticles int64
+	FailedArticles  int64
+	ServerStats     []nzbget.ServerStats
+	Parameters      map[string]string // post-processing parameters, from NZBPR_ variables.
+	Env             Env               // the whole environment, for options and anything else.
+}
+
+// parStatuses maps NZBPP_PARSTATUS values to ParStatus.
+var parStatuses = map[string]nzbget.ParStatus{ //nolint:gochecknoglobals
+	"0": nzbget.ParNONE,
+	"1": nzbget.ParFAILURE,
+	"2": nzbget.ParSUCCESS,
+	"3": nzbget.ParREPAIRPOSSIBLE,
+	"4": nzbget.ParMANUAL,
+}
+
+// unpackStatuses maps NZBPP_UNPACKSTATUS values to UnpackStatus.
+var unpackStatuses = map[string]nzbget.UnpackStatus{ //nolint:gochecknoglobals
+	"0": nzbget.UnpackNONE,
+	"1": nzbget.UnpackFAILURE,
+	"2": nzbget.UnpackSUCCESS,
+	"3": nzbget.UnpackSPACE,
+	"4": nzbget.UnpackPASSWORD,
+}
+
+// ParsePostProcess reads the post-processing context from the environment.
+// Use Environ() for the environment of the running script.
+func ParsePostProcess(env Env) (*PostProcess, error) {
+	if _, ok := env["NZBPP_DIRECTORY"]; !ok {
+		return nil, ErrNotPostProcess
+	}
+
+	post := &PostProcess{
+		NZBID:           env.Int("NZBPP_NZBID"),
+		Name:            env["NZBPP_NZBNAME"],
+		NZBFilename:     env["NZBPP_NZBFILENAME"],
+		Directory:       env["NZBPP_DIRECTORY"],
+		FinalDir:        env["NZBPP_FINALDIR"],
+		Category:        env["NZBPP_CATEGORY"],
+		URL:             env["NZBPP_URL"],
+		DupeKey:         env["NZBPP_DUPEKEY"],
+		DupeScore:       env.Int("NZBPP_DUPESCORE"),
+		DupeMode:        env["NZBPP_DUPEMODE"],
+		TotalStatus:     env["NZBPP_TOTALSTATUS"],
+		Status:          env["NZBPP_STATUS"],
+		ScriptStatus:    nzbget.ScriptStatus(env["NZBPP_SCRIPTSTATUS"]),
+		ParStatus:       parStatuses[env["NZBPP_PARSTATUS"]],
+		UnpackStatus:    unpackStatuses[env["NZBPP_UNPACKSTATUS"]],
+		Health:          env.Int("NZBPP_HEALTH"),
+		CriticalHealth:  env.Int("NZBPP_CRITICALHEALTH"),
+		TotalArticles:   env.Int("NZBPP_TOTALARTICLES"),
+		SuccessArticles: env.Int("NZBPP_SUCCESSARTICLES"),
+		FailedArticles:  env.Int("NZBPP_FAILEDARTICLES"),
+		Parameters:      env.Prefixed("NZBPR_"),
+		Env:             env,
+	}
+
+	post.ServerStats = serverStats(env)
+
+	return post, nil
+}
+
+// serverStats reads the NZBPP_SERVERn_ article counts, sorted by server ID.
+func serverStats(env Env) []nzbget.ServerStats {
+	output := []nzbget.ServerStats{}
+
+	for key := range env.Prefixed("NZBPP_SERVER") {
+		if !strings.HasSuffix(key, "_SUCCESSARTICLES") {
+			continue
+		}
+
+		id := strings.TrimSuffix(key, "_SUCCESSARTICLES")
+
+		serverID, err := strconv.ParseInt(id, 10, 64) //nolint:gomnd
+		if err != nil {
+			continue
+		}
+
+		output = append(output, nzbget.ServerStats{
+			ServerID:        serverID,
+			SuccessArticles: env.Int("NZBPP_SERVER" + key),
+			FailedArticles:  env.Int("NZBPP_SERVER" + id + "_FAILEDARTICLES"),
+		})
+	}
+
+	sort.Slice(output, func(i, j int) bool { return output[i].ServerID < output[j].ServerID })
+
+	return output
+}
+
+// Failed returns true if the download failed or was deleted.
+func (p *PostProcess) Failed() bool {
+	return p.TotalStatus == "FAILURE" || p.TotalStatus == "DELETED"
+}
+
+// Environ returns the environment NZBGet would pass to a post-processing script with this context.
+// Variables in Env are included, and overridden by the other fields.
+func (p *PostProcess) Environ() Env {
+	env := copyEnv(p.Env)
+	env.setAll("NZBPR_", p.Parameters)
+	env.setAll("NZBPP_", map[string]string{
+		"NZBID":           strconv.FormatInt(p.NZBID, 10),
+		"NZBNAME":         p.Name,
+		"NZBFILENAME":     p.NZBFilename,
+		"DIRECTORY":       p.Directory,
+		"FINALDIR":        p.FinalDir,
+		"CATEGORY":        p.Category,
+		"URL":             p.URL,
+		"DUPEKEY":         p.DupeKey,
+		"DUPESCORE":       strconv.FormatInt(p.DupeScore, 10),
+		"DUPEMODE":        p.DupeMode,
+		"TOTALSTATUS":     p.TotalStatus,
+		"STATUS":          p.Status,
+		"SCRIPTSTATUS":    string(p.ScriptStatus),
+		"PARSTATUS":       findKey(parStatuses, p.ParStatus),
+		"UNPACKSTATUS":    findKey(unpackStatuses, p.UnpackStatus),
+		"HEALTH":          strconv.FormatInt(p.Health, 10),
+		"CRITICALHEALTH":  strconv.FormatInt(p.CriticalHealth, 10),
+		"TOTALARTICLES":   strconv.FormatInt(p.TotalArticles, 10),
+		"SUCCESSARTICLES": strconv.FormatInt(p.SuccessArticles, 10),
+		"FAILEDARTICLES":  strconv.FormatInt(p.FailedArticles, 10),
+	})
+
+	for _, stats := range p.ServerStats {
+		prefix := "NZBPP_SERVER" + strconv.FormatInt(stats.ServerID, 10) + "_"
+		env[prefix+"SUCCESSARTICLES"] = strconv.FormatInt(stats.SuccessArticles, 10)
+		env[prefix+"FAILEDARTICLES"] = strconv.FormatInt(stats.FailedArticles, 10)
+	}
+
+	return env
+}
+
+// findKey returns the NZBGet number for a status, or "0" if it is unknown.
+func findKey[T comparable](statuses map[string]T, status T) string {
+	for key, val := range statuses {
+		if val == status {
+			return key
+		}
+	}
+
+	return "0"
+}
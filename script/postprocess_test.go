@@ -0,0 +1,172 @@
+package script
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"golift.io/nzbget"
+)
+
+func TestParsePostProcessStatuses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		parStatus    string
+		unpackStatus string
+		wantPar      nzbget.ParStatus
+		wantUnpack   nzbget.UnpackStatus
+	}{
+		{parStatus: "0", unpackStatus: "0", wantPar: nzbget.ParNONE, wantUnpack: nzbget.UnpackNONE},
+		{parStatus: "1", unpackStatus: "1", wantPar: nzbget.ParFAILURE, wantUnpack: nzbget.UnpackFAILURE},
+		{parStatus: "2", unpackStatus: "2", wantPar: nzbget.ParSUCCESS, wantUnpack: nzbget.UnpackSUCCESS},
+		{parStatus: "3", unpackStatus: "3", wantPar: nzbget.ParREPAIRPOSSIBLE, wantUnpack: nzbget.UnpackSPACE},
+		{parStatus: "4", unpackStatus: "4", wantPar: nzbget.ParMANUAL, wantUnpack: nzbget.UnpackPASSWORD},
+		{parStatus: "9", unpackStatus: "", wantPar: "", wantUnpack: ""},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.parStatus+"/"+test.unpackStatus, func(t *testing.T) {
+			t.Parallel()
+
+			post, err := ParsePostProcess(Env{
+				"NZBPP_DIRECTORY":    "/downloads/x",
+				"NZBPP_PARSTATUS":    test.parStatus,
+				"NZBPP_UNPACKSTATUS": test.unpackStatus,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if post.ParStatus != test.wantPar || post.UnpackStatus != test.wantUnpack {
+				t.Errorf("got %q/%q, want %q/%q", post.ParStatus, post.UnpackStatus, test.wantPar, test.wantUnpack)
+			}
+		})
+	}
+}
+
+func TestParsePostProcess(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParsePostProcess(Env{"NZBPP_NZBNAME": "x"}); !errors.Is(err, ErrNotPostProcess) {
+		t.Fatalf("expected ErrNotPostProcess, got: %v", err)
+	}
+
+	env := ParseEnv([]string{
+		"NZBPP_NZBID=42",
+		"NZBPP_NZBNAME=Show.S01E01",
+		"NZBPP_DIRECTORY=/downloads/Show.S01E01",
+		"NZBPP_CATEGORY=tv",
+		"NZBPP_TOTALSTATUS=FAILURE",
+		"NZBPP_STATUS=FAILURE/PAR",
+		"NZBPP_SCRIPTSTATUS=NONE",
+		"NZBPP_HEALTH=not a number",
+		"NZBPP_CRITICALHEALTH=900",
+		"NZBPP_SERVER2_SUCCESSARTICLES=20",
+		"NZBPP_SERVER2_FAILEDARTICLES=2",
+		"NZBPP_SERVER1_SUCCESSARTICLES=10",
+		"NZBPP_SERVERX_SUCCESSARTICLES=5",
+		"NZBPR_*Unpack:=yes",
+		"NZBPO_Verbose=yes",
+		"NZBOP_TEMPDIR=/tmp/nzbget",
+		"EMPTY=",
+		"NOVALUE",
+	})
+
+	post, err := ParsePostProcess(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	switch {
+	case post.NZBID != 42, post.Name != "Show.S01E01", post.Category != "tv":
+		t.Errorf("unexpected fields: %+v", post)
+	case post.Health != 0, post.CriticalHealth != 900:
+		t.Errorf("got health %d/%d, want 0/900", post.Health, post.CriticalHealth)
+	case !post.Failed():
+		t.Error("a FAILURE total status is failed")
+	case post.ScriptStatus != nzbget.ScriptNONE:
+		t.Errorf("got script status %q", post.ScriptStatus)
+	}
+
+	wantStats := []nzbget.ServerStats{{ServerID: 1, SuccessArticles: 10}, {ServerID: 2, SuccessArticles: 20, FailedArticles: 2}}
+	if !reflect.DeepEqual(post.ServerStats, wantStats) {
+		t.Errorf("got server stats %+v, want %+v", post.ServerStats, wantStats)
+	}
+
+	if !reflect.DeepEqual(post.Parameters, map[string]string{"*Unpack:": "yes"}) {
+		t.Errorf("got parameters %v", post.Parameters)
+	}
+
+	if !post.Env.OptionBool("Verbose") || post.Env.Global()["TEMPDIR"] != "/tmp/nzbget" {
+		t.Errorf("options and globals are not readable from Env: %v", post.Env)
+	}
+
+	if val, ok := env["EMPTY"]; !ok || val != "" {
+		t.Error("an empty variable was dropped")
+	}
+
+	if _, ok := env["NOVALUE"]; ok {
+		t.Error("a variable without = was parsed")
+	}
+}
+
+func TestPostProcessFailed(t *testing.T) {
+	t.Parallel()
+
+	for status, failed := range map[string]bool{"SUCCESS": false, "WARNING": false, "FAILURE": true, "DELETED": true} {
+		if got := (&PostProcess{TotalStatus: status}).Failed(); got != failed {
+			t.Errorf("%s: got failed %v, want %v", status, got, failed)
+		}
+	}
+}
+
+func TestPostProcessRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := &PostProcess{
+		NZBID:           7,
+		Name:            "Movie.2023",
+		NZBFilename:     "Movie.2023.nzb",
+		Directory:       "/downloads/Movie.2023",
+		FinalDir:        "/movies/Movie (2023)",
+		Category:        "movies",
+		URL:             "https://indexer/get/7",
+		DupeKey:         "tt123",
+		DupeScore:       100,
+		DupeMode:        "SCORE",
+		TotalStatus:     "WARNING",
+		Status:          "WARNING/DAMAGED",
+		ScriptStatus:    nzbget.ScriptSUCCESS,
+		ParStatus:       nzbget.ParREPAIRPOSSIBLE,
+		UnpackStatus:    nzbget.UnpackPASSWORD,
+		Health:          950,
+		CriticalHealth:  900,
+		TotalArticles:   1000,
+		SuccessArticles: 950,
+		FailedArticles:  50,
+		ServerStats:     []nzbget.ServerStats{{ServerID: 1, SuccessArticles: 900, FailedArticles: 50}, {ServerID: 3, SuccessArticles: 50}},
+		Parameters:      map[string]string{"*Unpack:Password": "secret"},
+		Env:             Env{"NZBPO_Verbose": "yes", "NZBPP_NZBNAME": "overridden"},
+	}
+
+	output, err := ParsePostProcess(input.Environ())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if input.Env["NZBPP_NZBNAME"] != "overridden" {
+		t.Error("Environ modified the input environment")
+	}
+
+	// The parsed Env is the whole environment, so compare it separately.
+	if output.Env.Option("Verbose") != "yes" || output.Env["NZBPP_NZBNAME"] != "Movie.2023" {
+		t.Errorf("unexpected environment: %v", output.Env)
+	}
+
+	output.Env, input.Env = nil, nil
+	if !reflect.DeepEqual(output, input) {
+		t.Errorf("round trip changed the context:\ngot  %+v\nwant %+v", output, input)
+	}
+}
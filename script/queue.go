@@ -0,0 +1,96 @@
+package script
+
+import (
+	"errors"
+	"strconv"
+
+	"golift.io/nzbget"
+)
+
+// ErrNotQueue is returned when the environment is not from a queue script run.
+var ErrNotQueue = errors.New("not running as a queue script: NZBNA_EVENT is missing")
+
+// QueueEvent is the reason NZBGet ran a queue script.
+type QueueEvent string
+
+// QueueEvents go here.
+const (
+	EventNZBAdded       QueueEvent = "NZB_ADDED"       // an nzb-file was added to the queue.
+	EventFileDownloaded QueueEvent = "FILE_DOWNLOADED" // one file of the download finished.
+	EventNZBDownloaded  QueueEvent = "NZB_DOWNLOADED"  // all files finished, before post-processing.
+	EventNZBDeleted     QueueEvent = "NZB_DELETED"     // the download was deleted from the queue.
+	EventNZBMarked      QueueEvent = "NZB_MARKED"      // the download was marked good or bad.
+	EventURLCompleted   QueueEvent = "URL_COMPLETED"   // an nzb-file URL was fetched, or failed to fetch.
+)
+
+// Queue is the context NZBGet passes to queue scripts in NZBNA_ variables.
+type Queue struct {
+	Event        QueueEvent
+	NZBID        int64
+	Name         string // NZBNA_NZBNAME
+	Filename     string // NZBNA_FILENAME, the nzb-file name.
+	Directory    string
+	Category     string
+	Priority     int64
+	URL          string
+	DupeKey      string
+	DupeScore    int64
+	DupeMode     string
+	DeleteStatus nzbget.DeleteStatus // for NZB_DELETED.
+	URLStatus    nzbget.URLStatus    // for URL_COMPLETED.
+	MarkStatus   nzbget.MarkStatus   // for NZB_MARKED.
+	Parameters   map[string]string   // post-processing parameters, from NZBPR_ variables.
+	Env          Env                 // the whole environment, for options and anything else.
+}
+
+// ParseQueue reads the queue script context from the environment.
+// Use Environ() for the environment of the running script.
+func ParseQueue(env Env) (*Queue, error) {
+	if _, ok := env["NZBNA_EVENT"]; !ok {
+		return nil, ErrNotQueue
+	}
+
+	return &Queue{
+		Event:        QueueEvent(env["NZBNA_EVENT"]),
+		NZBID:        env.Int("NZBNA_NZBID"),
+		Name:         env["NZBNA_NZBNAME"],
+		Filename:     env["NZBNA_FILENAME"],
+		Directory:    env["NZBNA_DIRECTORY"],
+		Category:     env["NZBNA_CATEGORY"],
+		Priority:     env.Int("NZBNA_PRIORITY"),
+		URL:          env["NZBNA_URL"],
+		DupeKey:      env["NZBNA_DUPEKEY"],
+		DupeScore:    env.Int("NZBNA_DUPESCORE"),
+		DupeMode:     env["NZBNA_DUPEMODE"],
+		DeleteStatus: nzbget.DeleteStatus(env["NZBNA_DELETESTATUS"]),
+		URLStatus:    nzbget.URLStatus(env["NZBNA_URLSTATUS"]),
+		MarkStatus:   nzbget.MarkStatus(env["NZBNA_MARKSTATUS"]),
+		Parameters:   env.Prefixed("NZBPR_"),
+		Env:          env,
+	}, nil
+}
+
+// Environ returns the environment NZBGet would pass to a queue script with this context.
+// Variables in Env are included, and overridden by the other fields.
+func (q *Queue) Environ() Env {
+	env := copyEnv(q.Env)
+	env.setAll("NZBPR_", q.Parameters)
+	env.setAll("NZBNA_", map[string]string{
+		"EVENT":        string(q.Event),
+		"NZBID":        strconv.FormatInt(q.NZBID, 10),
+		"NZBNAME":      q.Name,
+		"FILENAME":     q.Filename,
+		"DIRECTORY":    q.Directory,
+		"CATEGORY":     q.Category,
+		"PRIORITY":     strconv.FormatInt(q.Priority, 10),
+		"URL":          q.URL,
+		"DUPEKEY":      q.DupeKey,
+		"DUPESCORE":    strconv.FormatInt(q.DupeScore, 10),
+		"DUPEMODE":     q.DupeMode,
+		"DELETESTATUS": string(q.DeleteStatus),
+		"URLSTATUS":    string(q.URLStatus),
+		"MARKSTATUS":   string(q.MarkStatus),
+	})
+
+	return env
+}
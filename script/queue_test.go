@@ -0,0 +1,93 @@
+package script
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"golift.io/nzbget"
+)
+
+func TestParseQueue(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseQueue(Env{"NZBNA_NZBNAME": "x"}); !errors.Is(err, ErrNotQueue) {
+		t.Fatalf("expected ErrNotQueue, got: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		env  Env
+		want Queue
+	}{
+		{
+			name: "added",
+			env:  Env{"NZBNA_EVENT": "NZB_ADDED", "NZBNA_NZBID": "5", "NZBNA_PRIORITY": "-50", "NZBPR_Key": "val"},
+			want: Queue{Event: EventNZBAdded, NZBID: 5, Priority: -50, Parameters: map[string]string{"Key": "val"}},
+		},
+		{
+			name: "deleted",
+			env:  Env{"NZBNA_EVENT": "NZB_DELETED", "NZBNA_DELETESTATUS": "HEALTH", "NZBNA_DUPESCORE": "x"},
+			want: Queue{Event: EventNZBDeleted, DeleteStatus: nzbget.DeleteHEALTH, Parameters: map[string]string{}},
+		},
+		{
+			name: "marked",
+			env:  Env{"NZBNA_EVENT": "NZB_MARKED", "NZBNA_MARKSTATUS": "BAD", "NZBNA_DUPEKEY": "tt1"},
+			want: Queue{Event: EventNZBMarked, MarkStatus: nzbget.MarkBAD, DupeKey: "tt1", Parameters: map[string]string{}},
+		},
+		{
+			name: "url",
+			env:  Env{"NZBNA_EVENT": "URL_COMPLETED", "NZBNA_URLSTATUS": "FAILURE", "NZBNA_URL": "https://indexer/1"},
+			want: Queue{Event: EventURLCompleted, URLStatus: nzbget.URLFAILURE, URL: "https://indexer/1", Parameters: map[string]string{}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseQueue(test.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			test.want.Env = test.env
+			if !reflect.DeepEqual(*got, test.want) {
+				t.Errorf("got %+v, want %+v", *got, test.want)
+			}
+		})
+	}
+}
+
+func TestQueueRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := &Queue{
+		Event:        EventNZBDownloaded,
+		NZBID:        9,
+		Name:         "Album",
+		Filename:     "Album.nzb",
+		Directory:    "/downloads/Album",
+		Category:     "music",
+		Priority:     100,
+		URL:          "https://indexer/9",
+		DupeKey:      "album",
+		DupeScore:    10,
+		DupeMode:     "ALL",
+		DeleteStatus: nzbget.DeleteNONE,
+		URLStatus:    nzbget.URLNONE,
+		MarkStatus:   nzbget.MarkNONE,
+		Parameters:   map[string]string{"Key": "val"},
+	}
+
+	output, err := ParseQueue(input.Environ())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output.Env = nil
+	if !reflect.DeepEqual(output, input) {
+		t.Errorf("round trip changed the context:\ngot  %+v\nwant %+v", output, input)
+	}
+}
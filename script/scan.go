@@ -0,0 +1,79 @@
+package script
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrNotScan is returned when the environment is not from a scan script run.
+var ErrNotScan = errors.New("not running as a scan script: NZBNP_FILENAME is missing")
+
+// Scan is the context NZBGet passes to scan scripts in NZBNP_ variables.
+// Scan scripts run for every file found in the incoming nzb directory, and
+// may change how the nzb-file is queued with Output commands.
+type Scan struct {
+	Filename   string // full path of the found file.
+	Name       string // NZBNP_NZBNAME, the name the download gets in the queue.
+	Directory  string // the incoming nzb directory.
+	Category   string
+	Priority   int64
+	Top        bool // add to the top of the queue.
+	Paused     bool // add paused.
+	DupeKey    string
+	DupeScore  int64
+	DupeMode   string
+	Parameters map[string]string // post-processing parameters, from NZBPR_ variables.
+	Env        Env               // the whole environment, for options and anything else.
+}
+
+// ParseScan reads the scan script context from the environment.
+// Use Environ() for the environment of the running script.
+func ParseScan(env Env) (*Scan, error) {
+	if _, ok := env["NZBNP_FILENAME"]; !ok {
+		return nil, ErrNotScan
+	}
+
+	return &Scan{
+		Filename:   env["NZBNP_FILENAME"],
+		Name:       env["NZBNP_NZBNAME"],
+		Directory:  env["NZBNP_DIRECTORY"],
+		Category:   env["NZBNP_CATEGORY"],
+		Priority:   env.Int("NZBNP_PRIORITY"),
+		Top:        env["NZBNP_TOP"] == "1",
+		Paused:     env["NZBNP_PAUSED"] == "1",
+		DupeKey:    env["NZBNP_DUPEKEY"],
+		DupeScore:  env.Int("NZBNP_DUPESCORE"),
+		DupeMode:   env["NZBNP_DUPEMODE"],
+		Parameters: env.Prefixed("NZBPR_"),
+		Env:        env,
+	}, nil
+}
+
+// Environ returns the environment NZBGet would pass to a scan script with this context.
+// Variables in Env are included, and overridden by the other fields.
+func (s *Scan) Environ() Env {
+	env := copyEnv(s.Env)
+	env.setAll("NZBPR_", s.Parameters)
+	env.setAll("NZBNP_", map[string]string{
+		"FILENAME":  s.Filename,
+		"NZBNAME":   s.Name,
+		"DIRECTORY": s.Directory,
+		"CATEGORY":  s.Category,
+		"PRIORITY":  strconv.FormatInt(s.Priority, 10),
+		"TOP":       boolString(s.Top),
+		"PAUSED":    boolString(s.Paused),
+		"DUPEKEY":   s.DupeKey,
+		"DUPESCORE": strconv.FormatInt(s.DupeScore, 10),
+		"DUPEMODE":  s.DupeMode,
+	})
+
+	return env
+}
+
+func boolString(b bool) string {
+	if b {
+		return "1"
+	}
+
+	return "0"
+}
@@ -0,0 +1,63 @@
+package script
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseScan(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseScan(Env{"NZBNP_NZBNAME": "x"}); !errors.Is(err, ErrNotScan) {
+		t.Fatalf("expected ErrNotScan, got: %v", err)
+	}
+
+	tests := []struct {
+		top, paused         string
+		wantTop, wantPaused bool
+	}{
+		{top: "1", paused: "1", wantTop: true, wantPaused: true},
+		{top: "0", paused: "0"},
+		{top: "", paused: "yes"}, // NZBGet only sends 0 or 1.
+	}
+
+	for _, test := range tests {
+		scan, err := ParseScan(Env{"NZBNP_FILENAME": "/nzb/a.nzb", "NZBNP_TOP": test.top, "NZBNP_PAUSED": test.paused})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if scan.Top != test.wantTop || scan.Paused != test.wantPaused {
+			t.Errorf("top %q, paused %q: got %v, %v", test.top, test.paused, scan.Top, scan.Paused)
+		}
+	}
+}
+
+func TestScanRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := &Scan{
+		Filename:   "/nzb/Show.S01E01.nzb",
+		Name:       "Show.S01E01",
+		Directory:  "/nzb",
+		Category:   "tv",
+		Priority:   50,
+		Top:        true,
+		Paused:     true,
+		DupeKey:    "show-s01e01",
+		DupeScore:  5,
+		DupeMode:   "SCORE",
+		Parameters: map[string]string{"*Unpack:": "no"},
+	}
+
+	output, err := ParseScan(input.Environ())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output.Env = nil
+	if !reflect.DeepEqual(output, input) {
+		t.Errorf("round trip changed the context:\ngot  %+v\nwant %+v", output, input)
+	}
+}
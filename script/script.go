@@ -0,0 +1,230 @@
+// Package script helps write NZBGet extension scripts in Go.
+// NZBGet passes context to scripts in environment variables, reads commands
+// and log messages the script prints on stdout, and checks the exit code.
+// This package parses the environment into typed structs, prints commands,
+// and provides the exit codes NZBGet expects.
+package script
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ExitCode tells NZBGet the result of a script.
+type ExitCode int
+
+// ExitCodes go here.
+const (
+	ExitParCheck ExitCode = 92 // post-processing: request par-check and repair.
+	ExitSuccess  ExitCode = 93 // post-processing was successful.
+	ExitError    ExitCode = 94 // post-processing failed.
+	ExitNone     ExitCode = 95 // the script did nothing.
+)
+
+// Exit ends the script with an exit code.
+func Exit(code ExitCode) {
+	os.Exit(int(code))
+}
+
+// Main runs a script with the process environment and stdout, then exits with its exit code.
+// Writing scripts as a run function allows testing them with a Harness. Example:
+//
+//	func main() { script.Main(run) }
+//
+//	func run(env script.Env, out *script.Output) script.ExitCode { ... }
+func Main(run func(env Env, out *Output) ExitCode) {
+	Exit(run(Environ(), Stdout))
+}
+
+// Env holds the environment variables passed to a script.
+type Env map[string]string
+
+// Environ returns the environment of the running process.
+func Environ() Env {
+	return ParseEnv(os.Environ())
+}
+
+// ParseEnv turns a list of KEY=value strings, like os.Environ(), into an Env.
+func ParseEnv(environ []string) Env {
+	env := make(Env, len(environ))
+
+	for _, item := range environ {
+		if key, val, ok := strings.Cut(item, "="); ok {
+			env[key] = val
+		}
+	}
+
+	return env
+}
+
+// copyEnv returns a copy of env that is safe to modify.
+func copyEnv(env Env) Env {
+	output := make(Env, len(env))
+	for key, val := range env {
+		output[key] = val
+	}
+
+	return output
+}
+
+// setAll sets every value in vals, with prefix added to the keys.
+func (e Env) setAll(prefix string, vals map[string]string) {
+	for key, val := range vals {
+		e[prefix+key] = val
+	}
+}
+
+// Int returns an environment variable as an integer, or 0 if it is missing or invalid.
+func (e Env) Int(key string) int64 {
+	val, _ := strconv.ParseInt(e[key], 10, 64) //nolint:gomnd
+	return val
+}
+
+// Prefixed returns the environment variables that begin with prefix, with the prefix removed.
+func (e Env) Prefixed(prefix string) map[string]string {
+	output := make(map[string]string)
+
+	for key, val := range e {
+		if strings.HasPrefix(key, prefix) {
+			output[strings.TrimPrefix(key, prefix)] = val
+		}
+	}
+
+	return output
+}
+
+// Options returns the script options declared in the script header, from NZBPO_ variables.
+func (e Env) Options() map[string]string {
+	return e.Prefixed("NZBPO_")
+}
+
+// Option returns one script option declared in the script header.
+func (e Env) Option(name string) string {
+	return e["NZBPO_"+name]
+}
+
+// OptionBool returns true if a script option is set to yes, true, on or 1.
+func (e Env) OptionBool(name string) bool {
+	return ParseBool(e.Option(name))
+}
+
+// ParseBool returns true if an NZBGet option value is yes, true, on or 1, in any case.
+func ParseBool(val string) bool {
+	switch strings.ToLower(val) {
+	case "yes", "true", "1", "on":
+		return true
+	default:
+		return false
+	}
+}
+
+// Global returns NZBGet's own configuration options, from NZBOP_ variables.
+// Keys are upper-case, like TEMPDIR.
+func (e Env) Global() map[string]string {
+	return e.Prefixed("NZBOP_")
+}
+
+// Output prints commands and log messages for NZBGet to read.
+type Output struct {
+	w io.Writer
+}
+
+// NewOutput returns an Output that writes to w. Use os.Stdout in scripts.
+func NewOutput(w io.Writer) *Output {
+	return &Output{w: w}
+}
+
+// Stdout is an Output that writes to os.Stdout, where NZBGet reads it.
+var Stdout = NewOutput(os.Stdout) //nolint:gochecknoglobals
+
+// Command prints an [NZB] command, like DIRECTORY=/path.
+func (o *Output) Command(name, value string) {
+	fmt.Fprintf(o.w, "[NZB] %s=%s\n", name, value)
+}
+
+// Directory changes the destination directory of the download. For post-processing scripts.
+func (o *Output) Directory(dir string) {
+	o.Command("DIRECTORY", dir)
+}
+
+// FinalDir sets the final directory of the download, after a script moved the files. For post-processing scripts.
+func (o *Output) FinalDir(dir string) {
+	o.Command("FINALDIR", dir)
+}
+
+// Parameter sets a post-processing parameter on the download.
+func (o *Output) Parameter(name, value string) {
+	o.Command("NZBPR_"+name, value)
+}
+
+// NZBName changes the name of the download. For scan scripts.
+func (o *Output) NZBName(name string) {
+	o.Command("NZBNAME", name)
+}
+
+// Category changes the category of the download. For scan scripts.
+func (o *Output) Category(category string) {
+	o.Command("CATEGORY", category)
+}
+
+// Priority changes the priority of the download. For scan scripts.
+func (o *Output) Priority(priority int64) {
+	o.Command("PRIORITY", strconv.FormatInt(priority, 10))
+}
+
+// Top adds the download to the top of the queue. For scan scripts.
+func (o *Output) Top(top bool) {
+	o.Command("TOP", boolString(top))
+}
+
+// Paused adds the download paused. For scan scripts.
+func (o *Output) Paused(paused bool) {
+	o.Command("PAUSED", boolString(paused))
+}
+
+// DupeKey changes the duplicate key of the download. For scan scripts.
+func (o *Output) DupeKey(key string) {
+	o.Command("DUPEKEY", key)
+}
+
+// DupeScore changes the duplicate score of the download. For scan scripts.
+func (o *Output) DupeScore(score int64) {
+	o.Command("DUPESCORE", strconv.FormatInt(score, 10))
+}
+
+// DupeMode changes the duplicate mode of the download: SCORE, ALL or FORCE. For scan scripts.
+func (o *Output) DupeMode(mode string) {
+	o.Command("DUPEMODE", mode)
+}
+
+// MarkBad marks the download as bad, so duplicate handling can find another release.
+func (o *Output) MarkBad() {
+	o.Command("MARK", "BAD")
+}
+
+// Info prints an informational log message.
+func (o *Output) Info(format string, v ...interface{}) {
+	o.log("INFO", format, v...)
+}
+
+// Warning prints a warning log message.
+func (o *Output) Warning(format string, v ...interface{}) {
+	o.log("WARNING", format, v...)
+}
+
+// Error prints an error log message.
+func (o *Output) Error(format string, v ...interface{}) {
+	o.log("ERROR", format, v...)
+}
+
+// Detail prints a detail log message.
+func (o *Output) Detail(format string, v ...interface{}) {
+	o.log("DETAIL", format, v...)
+}
+
+func (o *Output) log(kind, format string, v ...interface{}) {
+	fmt.Fprintf(o.w, "[%s] %s\n", kind, fmt.Sprintf(format, v...))
+}
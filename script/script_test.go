@@ -0,0 +1,71 @@
+package script
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+)
+
+// TestExit runs the test binary again with SCRIPT_TEST_EXIT_CODE set, and checks the exit code it ends with.
+func TestExit(t *testing.T) {
+	t.Parallel()
+
+	if code := os.Getenv("SCRIPT_TEST_EXIT_CODE"); code != "" {
+		value, _ := strconv.Atoi(code)
+		Exit(ExitCode(value))
+	}
+
+	tests := []struct {
+		code ExitCode
+		want int
+	}{
+		{code: ExitParCheck, want: 92},
+		{code: ExitSuccess, want: 93},
+		{code: ExitError, want: 94},
+		{code: ExitNone, want: 95},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(strconv.Itoa(test.want), func(t *testing.T) {
+			t.Parallel()
+
+			cmd := exec.Command(os.Args[0], "-test.run=^TestExit$") //nolint:gosec
+			cmd.Env = append(os.Environ(), "SCRIPT_TEST_EXIT_CODE="+strconv.Itoa(int(test.code)))
+
+			var exitErr *exec.ExitError
+			if err := cmd.Run(); !errors.As(err, &exitErr) || exitErr.ExitCode() != test.want {
+				t.Errorf("got %v, want exit code %d", err, test.want)
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	env := Env{
+		"NZBPO_Enabled":  "Yes",
+		"NZBPO_Disabled": "no",
+		"NZBPO_One":      "1",
+		"NZBPO_Path":     "/data",
+		"NZBOP_TEMPDIR":  "/tmp",
+		"NZBPP_NZBID":    "12",
+	}
+
+	for name, want := range map[string]bool{"Enabled": true, "Disabled": false, "One": true, "Path": false, "Missing": false} {
+		if got := env.OptionBool(name); got != want {
+			t.Errorf("OptionBool(%s): got %v, want %v", name, got, want)
+		}
+	}
+
+	if options := env.Options(); len(options) != 4 || options["Path"] != "/data" {
+		t.Errorf("got options %v", options)
+	}
+
+	if env.Option("Path") != "/data" || env.Global()["TEMPDIR"] != "/tmp" || env.Int("NZBPP_NZBID") != 12 {
+		t.Errorf("unexpected values from %v", env)
+	}
+}
@@ -0,0 +1,72 @@
+package nzbget
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"golift.io/nzbget/internal/list"
+)
+
+// HistoryFilter selects history items for HistorySearch. Empty fields match every item.
+type HistoryFilter struct {
+	Hidden     bool           // Include hidden history records.
+	Since      time.Time      // Match items with a HistoryTime at or after this time.
+	Until      time.Time      // Match items with a HistoryTime before this time.
+	Categories []string       // Match items in any of these categories.
+	Statuses   []string       // Match items with any of these statuses, or status families like "FAILURE".
+	DupeKey    string         // Match items with this duplicate key.
+	Name       *regexp.Regexp // Match items with a Name matching this expression.
+	Limit      int            // Stop after this many matches. 0 is unlimited.
+}
+
+// Match returns true if the history item matches every field in the filter.
+func (f *HistoryFilter) Match(item *History) bool {
+	switch {
+	case !f.Since.IsZero() && item.HistoryTime.Before(f.Since),
+		!f.Until.IsZero() && !item.HistoryTime.Before(f.Until),
+		len(f.Categories) > 0 && !list.Contains(f.Categories, item.Category),
+		len(f.Statuses) > 0 && !list.ContainsFamily(f.Statuses, item.Status),
+		f.DupeKey != "" && item.DupeKey != f.DupeKey,
+		f.Name != nil && !f.Name.MatchString(item.Name):
+		return false
+	default:
+		return true
+	}
+}
+
+// HistorySearch returns the history items that match the filter.
+// Items are decoded one at a time while the response streams in, and only matches are kept.
+// The response is abandoned once Limit matches are found.
+func (n *NZBGet) HistorySearch(filter *HistoryFilter) ([]*History, error) {
+	return n.HistorySearchContext(context.Background(), filter)
+}
+
+// HistorySearchContext returns the history items that match the filter.
+// Items are decoded one at a time while the response streams in, and only matches are kept.
+// The response is abandoned once Limit matches are found.
+func (n *NZBGet) HistorySearchContext(ctx context.Context, filter *HistoryFilter) ([]*History, error) {
+	output := []*History{}
+	err := n.HistorySearchEach(ctx, filter, func(item *History) bool {
+		output = append(output, item)
+		return true
+	})
+
+	return output, err
+}
+
+// HistorySearchEach calls each for every history item that matches the filter, as it is decoded.
+// Return false from each to stop reading the history.
+func (n *NZBGet) HistorySearchEach(ctx context.Context, filter *HistoryFilter, each func(*History) bool) error {
+	found := 0
+
+	return n.HistoryEach(ctx, filter.Hidden, func(item *History) bool {
+		if !filter.Match(item) {
+			return true
+		}
+
+		found++
+
+		return each(item) && (filter.Limit <= 0 || found < filter.Limit)
+	})
+}
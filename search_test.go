@@ -0,0 +1,146 @@
+package nzbget
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHistoryFilterMatch(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	item := &History{
+		Name:        "Show.S01E01",
+		Category:    "tv",
+		Status:      "FAILURE/UNPACK",
+		DupeKey:     "tt123",
+		HistoryTime: Time{day},
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		match  bool
+	}{
+		{name: "empty", filter: HistoryFilter{}, match: true},
+		{name: "since equal", filter: HistoryFilter{Since: day}, match: true},
+		{name: "since after", filter: HistoryFilter{Since: day.Add(time.Second)}, match: false},
+		{name: "until after", filter: HistoryFilter{Until: day.Add(time.Second)}, match: true},
+		{name: "until equal", filter: HistoryFilter{Until: day}, match: false},
+		{name: "in range", filter: HistoryFilter{Since: day.Add(-time.Hour), Until: day.Add(time.Hour)}, match: true},
+		{name: "before range", filter: HistoryFilter{Since: day.Add(time.Hour), Until: day.Add(2 * time.Hour)}, match: false},
+		{name: "category", filter: HistoryFilter{Categories: []string{"movies", "tv"}}, match: true},
+		{name: "other category", filter: HistoryFilter{Categories: []string{"movies"}}, match: false},
+		{name: "status family", filter: HistoryFilter{Statuses: []string{"FAILURE"}}, match: true},
+		{name: "exact status", filter: HistoryFilter{Statuses: []string{"FAILURE/UNPACK"}}, match: true},
+		{name: "other status", filter: HistoryFilter{Statuses: []string{"SUCCESS", "FAILURE/PAR"}}, match: false},
+		{name: "status prefix", filter: HistoryFilter{Statuses: []string{"FAIL"}}, match: false},
+		{name: "dupe key", filter: HistoryFilter{DupeKey: "tt123"}, match: true},
+		{name: "other dupe key", filter: HistoryFilter{DupeKey: "tt999"}, match: false},
+		{name: "name", filter: HistoryFilter{Name: regexp.MustCompile(`S01E01$`)}, match: true},
+		{name: "other name", filter: HistoryFilter{Name: regexp.MustCompile(`S01E02`)}, match: false},
+		{name: "every field", filter: HistoryFilter{
+			Since: day, Categories: []string{"tv"}, Statuses: []string{"FAILURE"}, DupeKey: "tt123",
+		}, match: true},
+		{name: "one field fails", filter: HistoryFilter{
+			Since: day, Categories: []string{"tv"}, Statuses: []string{"SUCCESS"}, DupeKey: "tt123",
+		}, match: false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if match := test.filter.Match(item); match != test.match {
+				t.Errorf("got %v, want %v", match, test.match)
+			}
+		})
+	}
+}
+
+// newHistoryServer returns an NZBGet history endpoint with one item for each status,
+// and a flag set to 1 once a request asks for hidden items.
+func newHistoryServer(t *testing.T, statuses ...string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	history := make([]map[string]interface{}, len(statuses))
+	for idx, status := range statuses {
+		history[idx] = map[string]interface{}{"NZBID": idx + 1, "Status": status, "HistoryTime": 1700000000 + idx}
+	}
+
+	result, _ := json.Marshal(history)
+	hidden := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params json.RawMessage `json:"params"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err == nil && strings.Contains(string(req.Params), "true") {
+			atomic.StoreInt32(hidden, 1)
+		}
+
+		_, _ = w.Write([]byte(`{"version":"1.1","result":` + string(result) + `}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, hidden
+}
+
+func TestHistorySearchEach(t *testing.T) {
+	t.Parallel()
+
+	server, hidden := newHistoryServer(t, "SUCCESS/ALL", "FAILURE/PAR", "FAILURE/UNPACK", "DELETED/MANUAL", "FAILURE/HEALTH")
+	client := New(&Config{URL: server.URL})
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		stop   int // each returns false after this many calls. 0 never stops.
+		want   []int64
+	}{
+		{name: "all", filter: HistoryFilter{}, want: []int64{1, 2, 3, 4, 5}},
+		{name: "status family", filter: HistoryFilter{Statuses: []string{"FAILURE"}}, want: []int64{2, 3, 5}},
+		{name: "limit", filter: HistoryFilter{Statuses: []string{"FAILURE"}, Limit: 2}, want: []int64{2, 3}},
+		{name: "limit above matches", filter: HistoryFilter{Statuses: []string{"FAILURE"}, Limit: 10}, want: []int64{2, 3, 5}},
+		{name: "each stops", filter: HistoryFilter{}, stop: 1, want: []int64{1}},
+		{name: "since", filter: HistoryFilter{Since: time.Unix(1700000003, 0)}, want: []int64{4, 5}},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := []int64{}
+			err := client.HistorySearchEach(context.Background(), &test.filter, func(item *History) bool {
+				got = append(got, item.NZBID)
+				return test.stop == 0 || len(got) < test.stop
+			})
+
+			if err != nil || !sameIDs(got, test.want) {
+				t.Errorf("got %v, %v; want %v", got, err, test.want)
+			}
+		})
+	}
+
+	t.Run("hidden", func(t *testing.T) {
+		t.Parallel()
+
+		items, err := client.HistorySearchContext(context.Background(), &HistoryFilter{Hidden: true, Limit: 1})
+		if err != nil || len(items) != 1 || items[0].NZBID != 1 {
+			t.Errorf("got %v, %v; want item 1", items, err)
+		}
+
+		if atomic.LoadInt32(hidden) != 1 {
+			t.Error("the hidden parameter was not sent")
+		}
+	})
+}
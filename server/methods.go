@@ -0,0 +1,283 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// params are the positional parameters of an RPC call.
+type params []json.RawMessage
+
+// method decodes the parameters for one RPC method and calls the Service.
+type method func(ctx context.Context, service Service, args params) (interface{}, error)
+
+// methods maps RPC method names to Service methods.
+//
+//nolint:gochecknoglobals
+var methods = map[string]method{
+	"version": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.VersionContext(ctx)
+	},
+	"listfiles": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var idFrom, idTo, nzbID int64
+		if err := args.decode(&idFrom, &idTo, &nzbID); err != nil {
+			return nil, err
+		}
+
+		return s.ListFilesContext(ctx, nzbID)
+	},
+	"status": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.StatusContext(ctx)
+	},
+	"history": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var hidden bool
+		if err := args.decode(&hidden); err != nil {
+			return nil, err
+		}
+
+		return s.HistoryContext(ctx, hidden)
+	},
+	"listgroups": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.ListGroupsContext(ctx)
+	},
+	"log": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var startID, limit int64
+		if err := args.decode(&startID, &limit); err != nil {
+			return nil, err
+		}
+
+		return s.LogContext(ctx, startID, limit)
+	},
+	"loadlog": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var nzbID, startID, limit int64
+		if err := args.decode(&nzbID, &startID, &limit); err != nil {
+			return nil, err
+		}
+
+		return s.LoadLogContext(ctx, nzbID, startID, limit)
+	},
+	"config": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.ConfigContext(ctx)
+	},
+	"loadconfig": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.LoadConfigContext(ctx)
+	},
+	"saveconfig": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var configs []*nzbget.Parameter
+		if err := args.decode(&configs); err != nil {
+			return nil, err
+		}
+
+		return s.SaveConfigContext(ctx, configs)
+	},
+	"shutdown": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.ShutdownContext(ctx)
+	},
+	"reload": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.ReloadContext(ctx)
+	},
+	"rate": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var limit int64
+		if err := args.decode(&limit); err != nil {
+			return nil, err
+		}
+
+		return s.RateContext(ctx, limit)
+	},
+	"pausepost": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.PausePostContext(ctx)
+	},
+	"resumepost": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.ResumePostContext(ctx)
+	},
+	"pausedownload": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.PauseDownloadContext(ctx)
+	},
+	"resumedownload": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.ResumeDownloadContext(ctx)
+	},
+	"pausescan": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.PauseScanContext(ctx)
+	},
+	"resumescan": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.ResumeScanContext(ctx)
+	},
+	"scheduleresume": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var seconds float64
+		if err := args.decode(&seconds); err != nil {
+			return nil, err
+		}
+
+		return s.ScheduleResumeContext(ctx, time.Duration(seconds*float64(time.Second)))
+	},
+	"scan": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.ScanContext(ctx)
+	},
+	"writelog": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var (
+			kind nzbget.LogKind
+			text string
+		)
+
+		if err := args.decode(&kind, &text); err != nil {
+			return nil, err
+		}
+
+		return s.WriteLogContext(ctx, kind, text)
+	},
+	"configtemplates": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var loadFromDisk bool
+		if err := args.decode(&loadFromDisk); err != nil {
+			return nil, err
+		}
+
+		return s.ConfigTemplatesContext(ctx, loadFromDisk)
+	},
+	"servervolumes": func(ctx context.Context, s Service, _ params) (interface{}, error) {
+		return s.ServerVolumesContext(ctx)
+	},
+	"resetservervolume": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var (
+			serverID int64
+			counter  string
+		)
+
+		if err := args.decode(&serverID, &counter); err != nil {
+			return nil, err
+		}
+
+		return s.ResetServerVolumeContext(ctx, serverID, counter)
+	},
+	"append": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		input, err := args.appendInput()
+		if err != nil {
+			return nil, err
+		}
+
+		return s.AppendContext(ctx, input)
+	},
+	"editqueue": func(ctx context.Context, s Service, args params) (interface{}, error) {
+		var (
+			command, parameter string
+			offset             int64
+			ids                []int64
+		)
+
+		// NZBGet before v18 had an offset parameter before the edit parameter.
+		if len(args) > 3 { //nolint:gomnd
+			if err := args.decode(&command, &offset, &parameter, &ids); err != nil {
+				return nil, err
+			}
+
+			if parameter == "" && offset != 0 {
+				parameter = fmt.Sprint(offset)
+			}
+		} else if err := args.decode(&command, &parameter, &ids); err != nil {
+			return nil, err
+		}
+
+		return s.EditQueueContext(ctx, command, parameter, ids)
+	},
+}
+
+// arrayParams are methods whose first parameter is an array.
+//
+//nolint:gochecknoglobals
+var arrayParams = map[string]bool{"saveconfig": true}
+
+// newParams returns the positional parameters for a method.
+// The client in this library sends the parameter list wrapped in another
+// array, so a single array parameter is unwrapped unless the method expects one.
+func newParams(method string, raw json.RawMessage) (params, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	var args params
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("%w: params must be an array: %v", ErrInvalidParams, err)
+	}
+
+	if len(args) != 1 {
+		return args, nil
+	}
+
+	inner := bytes.TrimSpace(args[0])
+	if bytes.Equal(inner, []byte("null")) {
+		return nil, nil
+	} else if len(inner) == 0 || inner[0] != '[' {
+		return args, nil
+	}
+
+	var unwrapped params
+	if err := json.Unmarshal(inner, &unwrapped); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+	}
+
+	// An array method parameter is only wrapped if its first element is an array too.
+	if arrayParams[method] && (len(unwrapped) == 0 || !isArray(unwrapped[0])) {
+		return args, nil
+	}
+
+	return unwrapped, nil
+}
+
+func isArray(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '['
+}
+
+// decode unmarshals the parameters into dst in order. Missing parameters keep their zero value.
+func (p params) decode(dst ...interface{}) error {
+	for idx, val := range dst {
+		if idx >= len(p) {
+			return nil
+		}
+
+		if err := json.Unmarshal(p[idx], val); err != nil {
+			return fmt.Errorf("%w: parameter %d: %v", ErrInvalidParams, idx+1, err)
+		}
+	}
+
+	return nil
+}
+
+// appendInput decodes the parameters of the append method.
+func (p params) appendInput() (*nzbget.AppendInput, error) {
+	var (
+		input      nzbget.AppendInput
+		parameters []json.RawMessage
+	)
+
+	err := p.decode(&input.Filename, &input.Content, &input.Category, &input.Priority, &input.AddToTop,
+		&input.AddPaused, &input.DupeKey, &input.DupeScore, &input.DupeMode, &parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	// Post-processing parameters may be structs with Name and Value, or name-value pairs.
+	for _, raw := range parameters {
+		param := &nzbget.Parameter{}
+
+		if isArray(raw) {
+			var pair [2]string
+			if err := json.Unmarshal(raw, &pair); err != nil {
+				return nil, fmt.Errorf("%w: post-processing parameter: %v", ErrInvalidParams, err)
+			}
+
+			param.Name, param.Value = pair[0], pair[1]
+		} else if err := json.Unmarshal(raw, param); err != nil {
+			return nil, fmt.Errorf("%w: post-processing parameter: %v", ErrInvalidParams, err)
+		}
+
+		input.Parameters = append(input.Parameters, param)
+	}
+
+	return &input, nil
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method string
+		raw    string
+		want   []string // each parameter, as raw JSON.
+		err    error
+	}{
+		{name: "empty", method: "status", raw: ``},
+		{name: "null", method: "status", raw: `null`},
+		{name: "empty list", method: "status", raw: `[]`, want: []string{}},
+		{name: "wrapped null", method: "status", raw: `[null]`},
+		{name: "positional", method: "rate", raw: `[100]`, want: []string{`100`}},
+		{name: "many positional", method: "log", raw: `[0, 100]`, want: []string{`0`, `100`}},
+		{name: "wrapped", method: "log", raw: `[[0, 100]]`, want: []string{`0`, `100`}},
+		{name: "wrapped single", method: "history", raw: `[[true]]`, want: []string{`true`}},
+		{name: "array param", method: "saveconfig", raw: `[[{"Name":"a","Value":"b"}]]`,
+			want: []string{`[{"Name":"a","Value":"b"}]`}},
+		{name: "wrapped array param", method: "saveconfig", raw: `[[[{"Name":"a","Value":"b"}]]]`,
+			want: []string{`[{"Name":"a","Value":"b"}]`}},
+		{name: "editqueue ids", method: "editqueue", raw: `["GroupPause", "", [1, 2]]`,
+			want: []string{`"GroupPause"`, `""`, `[1, 2]`}},
+		{name: "not an array", method: "status", raw: `{"a":1}`, err: ErrInvalidParams},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := newParams(test.method, json.RawMessage(test.raw))
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+
+			var raw []string
+			if got != nil {
+				raw = make([]string, len(got))
+				for idx := range got {
+					raw[idx] = string(got[idx])
+				}
+			}
+
+			if !reflect.DeepEqual(raw, test.want) {
+				t.Errorf("got %q, want %q", raw, test.want)
+			}
+		})
+	}
+}
+
+func TestParamsDecode(t *testing.T) {
+	t.Parallel()
+
+	args, err := newParams("listfiles", json.RawMessage(`[[0, 0, 42]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first, second, nzbID, missing int64
+	if err := args.decode(&first, &second, &nzbID, &missing); err != nil {
+		t.Fatal(err)
+	}
+
+	if nzbID != 42 || missing != 0 {
+		t.Errorf("got nzbID %d and missing %d, want 42 and 0", nzbID, missing)
+	}
+
+	var name int64
+	if err := params([]json.RawMessage{json.RawMessage(`"x"`)}).decode(&name); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("expected ErrInvalidParams, got: %v", err)
+	}
+}
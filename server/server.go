@@ -0,0 +1,222 @@
+// Package server provides an http.Handler that speaks NZBGet's JSON-RPC and XML-RPC APIs.
+// Calls are decoded into a Service, an interface that mirrors the client methods, so proxies,
+// mocks and adapters can be written with the same types the client returns.
+//
+// The handler serves /jsonrpc and /xmlrpc paths, and accepts credentials with basic
+// auth or in the URL path like NZBGet does: /username:password/jsonrpc.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"golift.io/nzbget"
+)
+
+// DefaultMaxBodySize is the largest request body accepted. Append requests carry whole NZB files.
+const DefaultMaxBodySize = 100 << 20
+
+// Errors returned by the handler. They are sent to the caller as RPC errors.
+var (
+	ErrUnknownMethod = errors.New("invalid procedure")
+	ErrInvalidParams = errors.New("invalid parameter")
+)
+
+// Error codes used in the RPC errors sent to callers.
+const (
+	CodeInvalidProcedure = 1
+	CodeInvalidParameter = 2
+	CodeAccessDenied     = 3
+	CodeFailed           = 4
+)
+
+// Config is the input data needed to return a Handler.
+type Config struct {
+	// Authenticate returns true if the credentials are valid.
+	// If this is nil, every request is allowed.
+	Authenticate func(username, password string) bool
+	MaxBodySize  int64         // default: DefaultMaxBodySize
+	Logger       nzbget.Logger // optional, default: log.Default()
+}
+
+// Handler serves NZBGet RPC requests from a Service.
+type Handler struct {
+	service Service
+	config  *Config
+}
+
+// New returns an http.Handler that calls service for every RPC request.
+func New(service Service, config *Config) *Handler {
+	if config == nil {
+		config = &Config{}
+	}
+
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultMaxBodySize
+	}
+
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	return &Handler{service: service, config: config}
+}
+
+type contextKey struct{}
+
+// User returns the authenticated username for a request being served by a Handler.
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(contextKey{}).(string)
+	return user
+}
+
+// WithUser returns a context carrying username, as the Handler passes to the Service.
+// This is useful to call a Service directly as a specific user.
+func WithUser(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, contextKey{}, username)
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (h *Handler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	path, user, pass := credentials(req)
+
+	if h.config.Authenticate != nil && !h.config.Authenticate(user, pass) {
+		resp.Header().Set("WWW-Authenticate", `Basic realm="NZBGet"`)
+		http.Error(resp, "Access denied", http.StatusUnauthorized)
+
+		return
+	}
+
+	if req.Method != http.MethodPost {
+		http.Error(resp, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := WithUser(req.Context(), user)
+	body := http.MaxBytesReader(resp, req.Body, h.config.MaxBodySize)
+
+	switch {
+	case strings.HasSuffix(path, "/jsonrpc"):
+		h.serveJSON(ctx, resp, body)
+	case strings.HasSuffix(path, "/xmlrpc"):
+		h.serveXML(ctx, resp, body)
+	default:
+		http.NotFound(resp, req)
+	}
+}
+
+// credentials returns the request path without credentials, and the credentials
+// from basic auth, or from the first path element like /username:password/jsonrpc.
+func credentials(req *http.Request) (string, string, string) {
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	user, pass, _ := req.BasicAuth()
+
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2) //nolint:gomnd
+	if len(parts) == 2 && strings.Contains(parts[0], ":") {        //nolint:gomnd
+		path = "/" + parts[1]
+		user, pass, _ = strings.Cut(parts[0], ":")
+		user, _ = url.PathUnescape(user)
+		pass, _ = url.PathUnescape(pass)
+	}
+
+	return path, user, pass
+}
+
+// Call decodes the parameters for an RPC method and calls the Service.
+func (h *Handler) Call(ctx context.Context, method string, args json.RawMessage) (interface{}, error) {
+	call, ok := methods[strings.ToLower(method)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMethod, method)
+	}
+
+	params, err := newParams(strings.ToLower(method), args)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := call(ctx, h.service, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return emptyNil(result), nil
+}
+
+// emptyNil replaces nil slices with empty ones, so lists are never sent as null.
+func emptyNil(result interface{}) interface{} {
+	if val := reflect.ValueOf(result); val.Kind() == reflect.Slice && val.IsNil() {
+		return reflect.MakeSlice(val.Type(), 0, 0).Interface()
+	}
+
+	return result
+}
+
+// rpcError turns an error from a Service into an RPC error for the caller.
+func rpcError(err error) *nzbget.RPCError {
+	var rpcErr *nzbget.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	code := CodeFailed
+
+	switch {
+	case errors.Is(err, ErrUnknownMethod), errors.Is(err, ErrUnimplemented):
+		code = CodeInvalidProcedure
+	case errors.Is(err, ErrInvalidParams):
+		code = CodeInvalidParameter
+	}
+
+	return &nzbget.RPCError{Name: "JSONRPCError", Code: int64(code), Message: err.Error()}
+}
+
+// jsonRequest is an NZBGet JSON-RPC request.
+type jsonRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	ID     json.RawMessage `json:"id"`
+}
+
+// jsonResponse is an NZBGet JSON-RPC response.
+type jsonResponse struct {
+	Version string          `json:"version"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Result  interface{}     `json:"result"`
+}
+
+// jsonError is an NZBGet JSON-RPC error response.
+type jsonError struct {
+	Version string           `json:"version"`
+	ID      json.RawMessage  `json:"id,omitempty"`
+	Error   *nzbget.RPCError `json:"error"`
+}
+
+func (h *Handler) serveJSON(ctx context.Context, resp http.ResponseWriter, body io.Reader) {
+	var (
+		request jsonRequest
+		reply   interface{}
+	)
+
+	if err := json.NewDecoder(body).Decode(&request); err != nil {
+		err = fmt.Errorf("%w: decoding request: %v", ErrInvalidParams, err)
+		reply = &jsonError{Version: "1.1", Error: rpcError(err)}
+	} else if result, err := h.Call(ctx, request.Method, request.Params); err != nil {
+		reply = &jsonError{Version: "1.1", ID: request.ID, Error: rpcError(err)}
+	} else {
+		reply = &jsonResponse{Version: "1.1", ID: request.ID, Result: result}
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(resp).Encode(reply); err != nil {
+		h.config.Logger.Printf("[ERROR] nzbget server: writing response: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golift.io/nzbget"
+)
+
+// testService answers version and rate, and records the user that set the rate.
+type testService struct {
+	Unimplemented
+	rate int64
+	user string
+}
+
+func (s *testService) VersionContext(context.Context) (string, error) {
+	return "21.1", nil
+}
+
+func (s *testService) RateContext(ctx context.Context, limit int64) (bool, error) {
+	s.rate, s.user = limit, User(ctx)
+	return true, nil
+}
+
+func newTestServer(t *testing.T, service Service) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(New(service, &Config{
+		Authenticate: func(user, pass string) bool { return user == "user" && pass == "pass" },
+		Logger:       log.New(io.Discard, "", 0),
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestHandlerJSON(t *testing.T) {
+	t.Parallel()
+
+	service := &testService{}
+	server := newTestServer(t, service)
+	client := nzbget.New(&nzbget.Config{URL: server.URL, User: "user", Pass: "pass"})
+
+	if version, err := client.VersionContext(context.Background()); err != nil || version != "21.1" {
+		t.Fatalf("version: got %q, %v", version, err)
+	}
+
+	if ok, err := client.RateContext(context.Background(), 512); err != nil || !ok {
+		t.Fatalf("rate: got %v, %v", ok, err)
+	} else if service.rate != 512 || service.user != "user" {
+		t.Errorf("rate: service got %d from %q, want 512 from user", service.rate, service.user)
+	}
+
+	var rpcErr *nzbget.RPCError
+	if _, err := client.StatusContext(context.Background()); !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidProcedure {
+		t.Errorf("status: expected an RPCError with code %d, got: %v", CodeInvalidProcedure, err)
+	}
+
+	denied := nzbget.New(&nzbget.Config{URL: server.URL, User: "user", Pass: "wrong"})
+	if _, err := denied.VersionContext(context.Background()); err == nil {
+		t.Error("expected wrong credentials to fail")
+	}
+}
+
+func TestHandlerXML(t *testing.T) {
+	t.Parallel()
+
+	service := &testService{}
+	server := newTestServer(t, service)
+	body := `<?xml version="1.0"?><methodCall><methodName>rate</methodName>` +
+		`<params><param><value><i4>256</i4></value></param></params></methodCall>`
+
+	resp, err := http.Post(server.URL+"/user:pass/xmlrpc", "text/xml", strings.NewReader(body)) //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(data), "<boolean>1</boolean>") || service.rate != 256 {
+		t.Fatalf("unexpected response, rate %d: %s", service.rate, data)
+	}
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+// ErrUnimplemented is returned by Unimplemented methods.
+var ErrUnimplemented = errors.New("method not implemented")
+
+// Service mirrors the NZBGet client methods. *nzbget.NZBGet satisfies this
+// interface, so a Service can wrap a real NZBGet client to build a proxy.
+type Service interface {
+	VersionContext(ctx context.Context) (string, error)
+	ListFilesContext(ctx context.Context, nzbID int64) (*nzbget.File, error)
+	StatusContext(ctx context.Context) (*nzbget.Status, error)
+	HistoryContext(ctx context.Context, hidden bool) ([]*nzbget.History, error)
+	ListGroupsContext(ctx context.Context) ([]*nzbget.Group, error)
+	LogContext(ctx context.Context, startID, limit int64) ([]*nzbget.LogEntry, error)
+	LoadLogContext(ctx context.Context, nzbID, startID, limit int64) ([]*nzbget.LogEntry, error)
+	ConfigContext(ctx context.Context) ([]*nzbget.Parameter, error)
+	LoadConfigContext(ctx context.Context) ([]*nzbget.Parameter, error)
+	SaveConfigContext(ctx context.Context, configs []*nzbget.Parameter) (bool, error)
+	ShutdownContext(ctx context.Context) (bool, error)
+	ReloadContext(ctx context.Context) (bool, error)
+	RateContext(ctx context.Context, limit int64) (bool, error)
+	PausePostContext(ctx context.Context) (bool, error)
+	ResumePostContext(ctx context.Context) (bool, error)
+	PauseDownloadContext(ctx context.Context) (bool, error)
+	ResumeDownloadContext(ctx context.Context) (bool, error)
+	PauseScanContext(ctx context.Context) (bool, error)
+	ResumeScanContext(ctx context.Context) (bool, error)
+	ScheduleResumeContext(ctx context.Context, wait time.Duration) (bool, error)
+	ScanContext(ctx context.Context) (bool, error)
+	WriteLogContext(ctx context.Context, kind nzbget.LogKind, text string) (bool, error)
+	ConfigTemplatesContext(ctx context.Context, loadFromDisk bool) ([]*nzbget.ConfigTemplate, error)
+	ServerVolumesContext(ctx context.Context) ([]*nzbget.ServerVolume, error)
+	ResetServerVolumeContext(ctx context.Context, serverID int64, counter string) (bool, error)
+	AppendContext(ctx context.Context, input *nzbget.AppendInput) (int64, error)
+	EditQueueContext(ctx context.Context, command, parameter string, ids []int64) (bool, error)
+}
+
+var _ Service = (*nzbget.NZBGet)(nil)
+
+// Unimplemented returns ErrUnimplemented from every Service method.
+// Embed it in mocks and adapters that only implement some methods.
+type Unimplemented struct{}
+
+// VersionContext is not implemented.
+func (Unimplemented) VersionContext(context.Context) (string, error) {
+	return "", ErrUnimplemented
+}
+
+// ListFilesContext is not implemented.
+func (Unimplemented) ListFilesContext(context.Context, int64) (*nzbget.File, error) {
+	return nil, ErrUnimplemented
+}
+
+// StatusContext is not implemented.
+func (Unimplemented) StatusContext(context.Context) (*nzbget.Status, error) {
+	return nil, ErrUnimplemented
+}
+
+// HistoryContext is not implemented.
+func (Unimplemented) HistoryContext(context.Context, bool) ([]*nzbget.History, error) {
+	return nil, ErrUnimplemented
+}
+
+// ListGroupsContext is not implemented.
+func (Unimplemented) ListGroupsContext(context.Context) ([]*nzbget.Group, error) {
+	return nil, ErrUnimplemented
+}
+
+// LogContext is not implemented.
+func (Unimplemented) LogContext(context.Context, int64, int64) ([]*nzbget.LogEntry, error) {
+	return nil, ErrUnimplemented
+}
+
+// LoadLogContext is not implemented.
+func (Unimplemented) LoadLogContext(context.Context, int64, int64, int64) ([]*nzbget.LogEntry, error) {
+	return nil, ErrUnimplemented
+}
+
+// ConfigContext is not implemented.
+func (Unimplemented) ConfigContext(context.Context) ([]*nzbget.Parameter, error) {
+	return nil, ErrUnimplemented
+}
+
+// LoadConfigContext is not implemented.
+func (Unimplemented) LoadConfigContext(context.Context) ([]*nzbget.Parameter, error) {
+	return nil, ErrUnimplemented
+}
+
+// SaveConfigContext is not implemented.
+func (Unimplemented) SaveConfigContext(context.Context, []*nzbget.Parameter) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// ShutdownContext is not implemented.
+func (Unimplemented) ShutdownContext(context.Context) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// ReloadContext is not implemented.
+func (Unimplemented) ReloadContext(context.Context) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// RateContext is not implemented.
+func (Unimplemented) RateContext(context.Context, int64) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// PausePostContext is not implemented.
+func (Unimplemented) PausePostContext(context.Context) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// ResumePostContext is not implemented.
+func (Unimplemented) ResumePostContext(context.Context) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// PauseDownloadContext is not implemented.
+func (Unimplemented) PauseDownloadContext(context.Context) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// ResumeDownloadContext is not implemented.
+func (Unimplemented) ResumeDownloadContext(context.Context) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// PauseScanContext is not implemented.
+func (Unimplemented) PauseScanContext(context.Context) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// ResumeScanContext is not implemented.
+func (Unimplemented) ResumeScanContext(context.Context) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// ScheduleResumeContext is not implemented.
+func (Unimplemented) ScheduleResumeContext(context.Context, time.Duration) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// ScanContext is not implemented.
+func (Unimplemented) ScanContext(context.Context) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// WriteLogContext is not implemented.
+func (Unimplemented) WriteLogContext(context.Context, nzbget.LogKind, string) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// ConfigTemplatesContext is not implemented.
+func (Unimplemented) ConfigTemplatesContext(context.Context, bool) ([]*nzbget.ConfigTemplate, error) {
+	return nil, ErrUnimplemented
+}
+
+// ServerVolumesContext is not implemented.
+func (Unimplemented) ServerVolumesContext(context.Context) ([]*nzbget.ServerVolume, error) {
+	return nil, ErrUnimplemented
+}
+
+// ResetServerVolumeContext is not implemented.
+func (Unimplemented) ResetServerVolumeContext(context.Context, int64, string) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+// AppendContext is not implemented.
+func (Unimplemented) AppendContext(context.Context, *nzbget.AppendInput) (int64, error) {
+	return 0, ErrUnimplemented
+}
+
+// EditQueueContext is not implemented.
+func (Unimplemented) EditQueueContext(context.Context, string, string, []int64) (bool, error) {
+	return false, ErrUnimplemented
+}
+
+var _ Service = Unimplemented{}
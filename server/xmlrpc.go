@@ -0,0 +1,278 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xmlCall is an XML-RPC request.
+type xmlCall struct {
+	MethodName string     `xml:"methodName"`
+	Params     []xmlValue `xml:"params>param>value"`
+}
+
+// xmlValue is an XML-RPC value. Untyped values are strings.
+type xmlValue struct {
+	Int      *string    `xml:"int"`
+	I4       *string    `xml:"i4"`
+	I8       *string    `xml:"i8"`
+	Boolean  *string    `xml:"boolean"`
+	String   *string    `xml:"string"`
+	Double   *string    `xml:"double"`
+	Base64   *string    `xml:"base64"`
+	DateTime *string    `xml:"dateTime.iso8601"`
+	Struct   *xmlStruct `xml:"struct"`
+	Array    *xmlArray  `xml:"array"`
+	Text     string     `xml:",chardata"`
+}
+
+type xmlStruct struct {
+	Members []struct {
+		Name  string   `xml:"name"`
+		Value xmlValue `xml:"value"`
+	} `xml:"member"`
+}
+
+type xmlArray struct {
+	Values []xmlValue `xml:"data>value"`
+}
+
+// value returns the Go value of an XML-RPC value, as it would be decoded from JSON.
+// Base64 values are kept encoded, because NZBGet expects NZB content as base64 text.
+func (v *xmlValue) value() (interface{}, error) {
+	switch {
+	case v.Int != nil, v.I4 != nil, v.I8 != nil:
+		text := strings.TrimSpace(first(v.Int, v.I4, v.I8))
+
+		val, err := strconv.ParseInt(text, 10, 64) //nolint:gomnd
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+		}
+
+		return val, nil
+	case v.Double != nil:
+		val, err := strconv.ParseFloat(strings.TrimSpace(*v.Double), 64) //nolint:gomnd
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+		}
+
+		return val, nil
+	case v.Boolean != nil:
+		return strings.TrimSpace(*v.Boolean) == "1", nil
+	case v.String != nil:
+		return *v.String, nil
+	case v.Base64 != nil:
+		return strings.Join(strings.Fields(*v.Base64), ""), nil
+	case v.DateTime != nil:
+		val, err := time.Parse("20060102T15:04:05", strings.TrimSpace(*v.DateTime))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+		}
+
+		return val.Unix(), nil
+	case v.Struct != nil:
+		output := make(map[string]interface{}, len(v.Struct.Members))
+
+		for idx := range v.Struct.Members {
+			val, err := v.Struct.Members[idx].Value.value()
+			if err != nil {
+				return nil, err
+			}
+
+			output[v.Struct.Members[idx].Name] = val
+		}
+
+		return output, nil
+	case v.Array != nil:
+		output := make([]interface{}, len(v.Array.Values))
+
+		for idx := range v.Array.Values {
+			val, err := v.Array.Values[idx].value()
+			if err != nil {
+				return nil, err
+			}
+
+			output[idx] = val
+		}
+
+		return output, nil
+	default:
+		return v.Text, nil
+	}
+}
+
+func first(vals ...*string) string {
+	for _, val := range vals {
+		if val != nil {
+			return *val
+		}
+	}
+
+	return ""
+}
+
+func (h *Handler) serveXML(ctx context.Context, resp http.ResponseWriter, body io.Reader) {
+	resp.Header().Set("Content-Type", "text/xml")
+
+	result, callErr := h.callXML(ctx, body)
+	if callErr != nil {
+		rpcErr := rpcError(callErr)
+		result = map[string]interface{}{"faultCode": rpcErr.Code, "faultString": rpcErr.Message}
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(xml.Header + "<methodResponse>")
+
+	if callErr != nil {
+		buf.WriteString("<fault><value>")
+	} else {
+		buf.WriteString("<params><param><value>")
+	}
+
+	if err := writeXMLValue(&buf, result); err != nil {
+		h.config.Logger.Printf("[ERROR] nzbget server: encoding response: %v", err)
+		http.Error(resp, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if callErr != nil {
+		buf.WriteString("</value></fault>")
+	} else {
+		buf.WriteString("</value></param></params>")
+	}
+
+	buf.WriteString("</methodResponse>\n")
+
+	if _, err := buf.WriteTo(resp); err != nil {
+		h.config.Logger.Printf("[ERROR] nzbget server: writing response: %v", err)
+	}
+}
+
+// callXML decodes an XML-RPC request and calls the method with its parameters converted to JSON.
+func (h *Handler) callXML(ctx context.Context, body io.Reader) (interface{}, error) {
+	var call xmlCall
+	if err := xml.NewDecoder(body).Decode(&call); err != nil {
+		return nil, fmt.Errorf("%w: decoding request: %v", ErrInvalidParams, err)
+	}
+
+	args := make([]interface{}, len(call.Params))
+
+	for idx := range call.Params {
+		val, err := call.Params[idx].value()
+		if err != nil {
+			return nil, err
+		}
+
+		args[idx] = val
+	}
+
+	params, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParams, err)
+	}
+
+	return h.Call(ctx, call.MethodName, params)
+}
+
+// writeXMLValue encodes a value as XML-RPC. The value is converted to JSON first,
+// so it is encoded with the same field names and formats as a JSON-RPC response.
+func writeXMLValue(buf *bytes.Buffer, val interface{}) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Errorf("encoding result: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return writeXMLToken(buf, dec)
+}
+
+// writeXMLToken writes the next JSON value from dec as XML-RPC, keeping the order of struct fields.
+func writeXMLToken(buf *bytes.Buffer, dec *json.Decoder) error {
+	token, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("encoding result: %w", err)
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		return writeXMLContainer(buf, dec, token)
+	case string:
+		buf.WriteString("<string>")
+		_ = xml.EscapeText(buf, []byte(token))
+		buf.WriteString("</string>")
+	case bool:
+		if token {
+			buf.WriteString("<boolean>1</boolean>")
+		} else {
+			buf.WriteString("<boolean>0</boolean>")
+		}
+	case json.Number:
+		if val, err := token.Int64(); err == nil && val >= math.MinInt32 && val <= math.MaxInt32 {
+			fmt.Fprintf(buf, "<i4>%d</i4>", val)
+		} else {
+			fmt.Fprintf(buf, "<double>%s</double>", token)
+		}
+	case nil:
+		buf.WriteString("<nil/>")
+	}
+
+	return nil
+}
+
+func writeXMLContainer(buf *bytes.Buffer, dec *json.Decoder, delim json.Delim) error {
+	if delim == '[' {
+		buf.WriteString("<array><data>")
+	} else {
+		buf.WriteString("<struct>")
+	}
+
+	for dec.More() {
+		if delim == '{' {
+			key, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("encoding result: %w", err)
+			}
+
+			buf.WriteString("<member><name>")
+			_ = xml.EscapeText(buf, []byte(fmt.Sprint(key)))
+			buf.WriteString("</name>")
+		}
+
+		buf.WriteString("<value>")
+
+		if err := writeXMLToken(buf, dec); err != nil {
+			return err
+		}
+
+		buf.WriteString("</value>")
+
+		if delim == '{' {
+			buf.WriteString("</member>")
+		}
+	}
+
+	if _, err := dec.Token(); err != nil { // closing delimiter.
+		return fmt.Errorf("encoding result: %w", err)
+	}
+
+	if delim == '[' {
+		buf.WriteString("</data></array>")
+	} else {
+		buf.WriteString("</struct>")
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestXMLValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		xml  string
+		want interface{}
+		err  error
+	}{
+		{name: "untyped", xml: `<value>hello</value>`, want: "hello"},
+		{name: "string", xml: `<value><string> spaced </string></value>`, want: " spaced "},
+		{name: "int", xml: `<value><int>42</int></value>`, want: int64(42)},
+		{name: "i4", xml: `<value><i4> -7 </i4></value>`, want: int64(-7)},
+		{name: "i8", xml: `<value><i8>8589934592</i8></value>`, want: int64(8589934592)},
+		{name: "bad int", xml: `<value><int>four</int></value>`, err: ErrInvalidParams},
+		{name: "double", xml: `<value><double>1.5</double></value>`, want: 1.5},
+		{name: "true", xml: `<value><boolean>1</boolean></value>`, want: true},
+		{name: "false", xml: `<value><boolean>0</boolean></value>`, want: false},
+		{name: "base64", xml: "<value><base64>QUJD\nREVG</base64></value>", want: "QUJDREVG"},
+		{name: "date", xml: `<value><dateTime.iso8601>20231114T22:13:20</dateTime.iso8601></value>`, want: int64(1700000000)},
+		{name: "bad date", xml: `<value><dateTime.iso8601>yesterday</dateTime.iso8601></value>`, err: ErrInvalidParams},
+		{
+			name: "array",
+			xml:  `<value><array><data><value><int>1</int></value><value>two</value></data></array></value>`,
+			want: []interface{}{int64(1), "two"},
+		},
+		{
+			name: "struct",
+			xml: `<value><struct><member><name>Name</name><value>a</value></member>` +
+				`<member><name>Value</name><value><int>2</int></value></member></struct></value>`,
+			want: map[string]interface{}{"Name": "a", "Value": int64(2)},
+		},
+		{
+			name: "nested error",
+			xml:  `<value><array><data><value><double>x</double></value></data></array></value>`,
+			err:  ErrInvalidParams,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var val xmlValue
+			if err := xml.Unmarshal([]byte(test.xml), &val); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := val.value()
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Table is one section of a report, as rows of text.
+type Table struct {
+	Name   string
+	Header []string
+	Rows   [][]string
+}
+
+// Tables returns the report sections: families, outcomes, volume, timings and servers.
+// Bytes and seconds are plain numbers, so the tables can be loaded into a spreadsheet.
+func (r *Report) Tables() []*Table {
+	tables := []*Table{
+		outcomeTable("families", r.Families),
+		outcomeTable("outcomes", r.Outcomes),
+		{Name: "volume", Header: []string{"Day", "Category", "Count", "Bytes"}},
+		{Name: "timings", Header: []string{"Stage", "Count", "TotalSec", "AverageSec"}},
+		{Name: "servers", Header: []string{"Server", "Success", "Failed", "Ratio"}},
+	}
+
+	for _, volume := range r.Volume {
+		tables[2].Rows = append(tables[2].Rows, []string{volume.Day.Format("2006-01-02"),
+			volume.Category, strconv.Itoa(volume.Count), strconv.FormatInt(volume.Bytes, 10)})
+	}
+
+	for _, timing := range r.Timings {
+		tables[3].Rows = append(tables[3].Rows, []string{timing.Stage, strconv.Itoa(timing.Count),
+			strconv.FormatInt(timing.TotalSec, 10), strconv.FormatFloat(timing.AverageSec, 'f', 1, 64)})
+	}
+
+	for _, server := range r.Servers {
+		tables[4].Rows = append(tables[4].Rows, []string{strconv.FormatInt(server.ServerID, 10),
+			strconv.FormatInt(server.Success, 10), strconv.FormatInt(server.Failed, 10), ratio(server.Ratio)})
+	}
+
+	return tables
+}
+
+func outcomeTable(name string, outcomes []*Outcome) *Table {
+	table := &Table{Name: name, Header: []string{"Status", "Count", "Rate"}}
+
+	for _, outcome := range outcomes {
+		table.Rows = append(table.Rows, []string{outcome.Status, strconv.Itoa(outcome.Count), ratio(outcome.Rate)})
+	}
+
+	return table
+}
+
+func ratio(val float64) string {
+	return strconv.FormatFloat(val, 'f', 4, 64) //nolint:gomnd
+}
+
+// WriteText writes the report as aligned text tables, with readable sizes and durations.
+func (r *Report) WriteText(w io.Writer) error {
+	tab := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd
+
+	fmt.Fprintf(tab, "Records:\t%d\n", r.Count)
+	fmt.Fprintf(tab, "Period:\t%s - %s\n", r.From.In(r.location()).Format(time.RFC1123),
+		r.To.In(r.location()).Format(time.RFC1123))
+	fmt.Fprintf(tab, "Downloaded:\t%s\n", size(r.Bytes))
+
+	for _, table := range r.Tables() {
+		fmt.Fprintf(tab, "\n%s\n", strings.ToUpper(table.Name))
+		fmt.Fprintln(tab, strings.Join(table.Header, "\t"))
+
+		for _, row := range table.Rows {
+			fmt.Fprintln(tab, strings.Join(readable(table.Name, row), "\t"))
+		}
+	}
+
+	if err := tab.Flush(); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	return nil
+}
+
+// readable formats the numbers in a table row for the text report.
+func readable(table string, row []string) []string {
+	row = append([]string{}, row...)
+
+	switch table {
+	case "volume":
+		bytes, _ := strconv.ParseInt(row[3], 10, 64) //nolint:gomnd
+		row[3] = size(bytes)
+	case "timings":
+		total, _ := strconv.ParseInt(row[2], 10, 64) //nolint:gomnd
+		average, _ := strconv.ParseFloat(row[3], 64) //nolint:gomnd
+		row[2] = (time.Duration(total) * time.Second).String()
+		row[3] = time.Duration(average * float64(time.Second)).Round(time.Second).String()
+	case "families", "outcomes", "servers":
+		rate, _ := strconv.ParseFloat(row[len(row)-1], 64)                //nolint:gomnd
+		row[len(row)-1] = strconv.FormatFloat(rate*100, 'f', 1, 64) + "%" //nolint:gomnd
+	}
+
+	return row
+}
+
+// WriteCSV writes one table as CSV, with a header row.
+func (t *Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write(t.Header)
+	_ = writer.WriteAll(t.Rows)
+
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+
+	return nil
+}
+
+// WriteCSV writes every table as CSV. Each table starts with a row holding its name,
+// then its header row, and ends with an empty row.
+func (r *Report) WriteCSV(w io.Writer) error {
+	for _, table := range r.Tables() {
+		if _, err := fmt.Fprintf(w, "%s\n", table.Name); err != nil {
+			return fmt.Errorf("writing csv: %w", err)
+		}
+
+		if err := table.WriteCSV(w); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(w); err != nil {
+			return fmt.Errorf("writing csv: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Report) location() *time.Location {
+	if r.loc == nil {
+		return time.Local
+	}
+
+	return r.loc
+}
+
+// size formats bytes for display, like 1.2 GB.
+func size(bytes int64) string {
+	const kibibyte = 1024
+
+	units := []string{"B", "KB", "MB", "GB", "TB", "PB"}
+	val := float64(bytes)
+	unit := 0
+
+	for val >= kibibyte && unit < len(units)-1 {
+		val /= kibibyte
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	return fmt.Sprintf("%.1f %s", val, units[unit])
+}
@@ -0,0 +1,246 @@
+// Package stats computes download statistics from NZBGet history: bytes per category
+// and day, outcomes by status, average stage times and per-server article success.
+// Reports are built from History records or a history archive, and are written as
+// text tables, CSV or JSON.
+package stats
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/archiver"
+)
+
+// Report is the statistics for a set of history records.
+type Report struct {
+	From     time.Time  `json:"from"` // HistoryTime of the oldest record.
+	To       time.Time  `json:"to"`   // HistoryTime of the newest record.
+	Count    int        `json:"count"`
+	Bytes    int64      `json:"bytes"` // downloaded bytes.
+	Families []*Outcome `json:"families"`
+	Outcomes []*Outcome `json:"outcomes"`
+	Volume   []*Volume  `json:"volume"`
+	Timings  []*Timing  `json:"timings"`
+	Servers  []*Server  `json:"servers"`
+	loc      *time.Location
+	families map[string]*Outcome
+	outcomes map[string]*Outcome
+	volume   map[volumeKey]*Volume
+	timings  []*Timing
+	servers  map[int64]*Server
+}
+
+// Outcome counts the records with a history status, like FAILURE/PAR,
+// or a status family, like FAILURE.
+type Outcome struct {
+	Status string  `json:"status"`
+	Count  int     `json:"count"`
+	Rate   float64 `json:"rate"` // fraction of all records, 0 to 1.
+}
+
+// Volume is the downloads in one category on one day.
+type Volume struct {
+	Day      time.Time `json:"day"` // midnight, in the report location.
+	Category string    `json:"category"`
+	Count    int       `json:"count"`
+	Bytes    int64     `json:"bytes"`
+}
+
+type volumeKey struct {
+	day      time.Time
+	category string
+}
+
+// Timing is the time spent in one processing stage. Records that skipped the stage are not counted.
+type Timing struct {
+	Stage      string  `json:"stage"` // download, par, repair, unpack or post.
+	Count      int     `json:"count"`
+	TotalSec   int64   `json:"totalSec"`
+	AverageSec float64 `json:"averageSec"`
+	seconds    func(*nzbget.History) int64
+}
+
+// Server is the article counts for one news server.
+type Server struct {
+	ServerID int64   `json:"serverId"`
+	Success  int64   `json:"success"`
+	Failed   int64   `json:"failed"`
+	Ratio    float64 `json:"ratio"` // successful fraction of all articles, 0 to 1.
+}
+
+// New returns a report for the history records. Days are split at midnight in loc,
+// or in local time if loc is nil.
+func New(history []*nzbget.History, loc *time.Location) *Report {
+	report := newReport(loc)
+
+	for _, item := range history {
+		report.add(item)
+	}
+
+	return report.finish()
+}
+
+// FromArchive returns a report for the archived records that match the filter. A nil filter matches every record.
+func FromArchive(store *archiver.Store, filter *nzbget.HistoryFilter, loc *time.Location) (*Report, error) {
+	report := newReport(loc)
+
+	err := store.Each(filter, func(item *nzbget.History) bool {
+		report.add(item)
+		return true
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped by the archiver.
+	}
+
+	return report.finish(), nil
+}
+
+func newReport(loc *time.Location) *Report {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	return &Report{
+		loc:      loc,
+		families: make(map[string]*Outcome),
+		outcomes: make(map[string]*Outcome),
+		volume:   make(map[volumeKey]*Volume),
+		servers:  make(map[int64]*Server),
+		timings: []*Timing{
+			{Stage: "download", seconds: func(item *nzbget.History) int64 { return item.DownloadTimeSec }},
+			{Stage: "par", seconds: func(item *nzbget.History) int64 { return item.ParTimeSec }},
+			{Stage: "repair", seconds: func(item *nzbget.History) int64 { return item.RepairTimeSec }},
+			{Stage: "unpack", seconds: func(item *nzbget.History) int64 { return item.UnpackTimeSec }},
+			{Stage: "post", seconds: func(item *nzbget.History) int64 { return item.PostTotalTimeSec }},
+		},
+	}
+}
+
+// add counts one history record.
+func (r *Report) add(item *nzbget.History) {
+	r.Count++
+	bytes := nzbget.JoinSize(item.DownloadedSizeHi, item.DownloadedSizeLo)
+	r.Bytes += bytes
+
+	if r.From.IsZero() || item.HistoryTime.Before(r.From) {
+		r.From = item.HistoryTime.Time
+	}
+
+	if item.HistoryTime.After(r.To) {
+		r.To = item.HistoryTime.Time
+	}
+
+	family, _, _ := strings.Cut(item.Status, "/")
+	count(r.families, family)
+	count(r.outcomes, item.Status)
+
+	t := item.HistoryTime.In(r.loc)
+	key := volumeKey{day: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, r.loc), category: item.Category}
+
+	volume := r.volume[key]
+	if volume == nil {
+		volume = &Volume{Day: key.day, Category: key.category}
+		r.volume[key] = volume
+	}
+
+	volume.Count++
+	volume.Bytes += bytes
+
+	for _, timing := range r.timings {
+		if seconds := timing.seconds(item); seconds > 0 {
+			timing.Count++
+			timing.TotalSec += seconds
+		}
+	}
+
+	for _, stats := range item.ServerStats {
+		server := r.servers[stats.ServerID]
+		if server == nil {
+			server = &Server{ServerID: stats.ServerID}
+			r.servers[stats.ServerID] = server
+		}
+
+		server.Success += stats.SuccessArticles
+		server.Failed += stats.FailedArticles
+	}
+}
+
+func count(outcomes map[string]*Outcome, status string) {
+	if outcomes[status] == nil {
+		outcomes[status] = &Outcome{Status: status}
+	}
+
+	outcomes[status].Count++
+}
+
+// finish computes rates and averages, and sorts the report.
+func (r *Report) finish() *Report {
+	r.Families = sortOutcomes(r.families, r.Count)
+	r.Outcomes = sortOutcomes(r.outcomes, r.Count)
+
+	r.Volume = make([]*Volume, 0, len(r.volume))
+	for _, volume := range r.volume {
+		r.Volume = append(r.Volume, volume)
+	}
+
+	sort.Slice(r.Volume, func(i, j int) bool {
+		if !r.Volume[i].Day.Equal(r.Volume[j].Day) {
+			return r.Volume[i].Day.Before(r.Volume[j].Day)
+		}
+
+		return r.Volume[i].Category < r.Volume[j].Category
+	})
+
+	r.Timings = r.timings
+	for _, timing := range r.Timings {
+		if timing.Count > 0 {
+			timing.AverageSec = float64(timing.TotalSec) / float64(timing.Count)
+		}
+	}
+
+	r.Servers = make([]*Server, 0, len(r.servers))
+	for _, server := range r.servers {
+		if total := server.Success + server.Failed; total > 0 {
+			server.Ratio = float64(server.Success) / float64(total)
+		}
+
+		r.Servers = append(r.Servers, server)
+	}
+
+	sort.Slice(r.Servers, func(i, j int) bool { return r.Servers[i].ServerID < r.Servers[j].ServerID })
+
+	return r
+}
+
+// sortOutcomes returns the outcomes with their rates, most common first.
+func sortOutcomes(outcomes map[string]*Outcome, total int) []*Outcome {
+	output := make([]*Outcome, 0, len(outcomes))
+
+	for _, outcome := range outcomes {
+		outcome.Rate = float64(outcome.Count) / float64(total)
+		output = append(output, outcome)
+	}
+
+	sort.Slice(output, func(i, j int) bool {
+		if output[i].Count != output[j].Count {
+			return output[i].Count > output[j].Count
+		}
+
+		return output[i].Status < output[j].Status
+	})
+
+	return output
+}
+
+// Rate returns the fraction of records in a status family, like SUCCESS or FAILURE, from 0 to 1.
+func (r *Report) Rate(family string) float64 {
+	for _, outcome := range r.Families {
+		if outcome.Status == family {
+			return outcome.Rate
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,231 @@
+package stats
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/archiver"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata") //nolint:gochecknoglobals
+
+// cet is the report location. It moves a record from late on March 1st UTC to March 2nd.
+var cet = time.FixedZone("CET", 3600) //nolint:gochecknoglobals
+
+// sampleHistory returns history records over two days, in two categories, with every status family.
+func sampleHistory() []*nzbget.History {
+	at := func(day, hour, minute int) nzbget.Time {
+		return nzbget.Time{Time: time.Date(2024, 3, day, hour, minute, 0, 0, time.UTC)}
+	}
+
+	return []*nzbget.History{
+		{
+			NZBID: 1, Category: "tv", Status: "SUCCESS/ALL", HistoryTime: at(1, 10, 0),
+			DownloadedSizeLo: 3 << 30, DownloadTimeSec: 300, ParTimeSec: 20, UnpackTimeSec: 40, PostTotalTimeSec: 70,
+			ServerStats: []nzbget.ServerStats{
+				{ServerID: 1, SuccessArticles: 990, FailedArticles: 10},
+				{ServerID: 2, SuccessArticles: 50},
+			},
+		},
+		{
+			NZBID: 2, Category: "tv", Status: "FAILURE/PAR", HistoryTime: at(1, 23, 30),
+			DownloadedSizeLo: 500 << 20, DownloadTimeSec: 100, ParTimeSec: 30, RepairTimeSec: 60, PostTotalTimeSec: 95,
+			ServerStats: []nzbget.ServerStats{{ServerID: 1, SuccessArticles: 900, FailedArticles: 100}},
+		},
+		{
+			NZBID: 3, Category: "movies", Status: "SUCCESS/UNPACK", HistoryTime: at(1, 12, 0),
+			DownloadedSizeHi: 1, DownloadedSizeLo: 2 << 30, DownloadTimeSec: 600, UnpackTimeSec: 120, PostTotalTimeSec: 130,
+			ServerStats: []nzbget.ServerStats{{ServerID: 2, SuccessArticles: 4000}},
+		},
+		{NZBID: 4, Category: "movies", Status: "DELETED/MANUAL", HistoryTime: at(2, 8, 0)},
+	}
+}
+
+// golden compares got with a file in testdata, or rewrites the file with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func near(got, want float64) bool {
+	return math.Abs(got-want) < 1e-9
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	report := New(sampleHistory(), cet)
+
+	if report.Count != 4 || report.Bytes != 9<<30+500<<20 {
+		t.Errorf("got %d records and %d bytes", report.Count, report.Bytes)
+	}
+
+	if !report.From.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) || !report.To.Equal(time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("got period %v to %v", report.From, report.To)
+	}
+
+	for family, want := range map[string]float64{"SUCCESS": 0.5, "FAILURE": 0.25, "DELETED": 0.25, "WARNING": 0} {
+		if got := report.Rate(family); !near(got, want) {
+			t.Errorf("%s: got rate %v, want %v", family, got, want)
+		}
+	}
+
+	// The most common family is first, and ties are sorted by status.
+	if report.Families[0].Status != "SUCCESS" || report.Families[1].Status != "DELETED" || len(report.Outcomes) != 4 {
+		t.Errorf("got families %v and %d outcomes", report.Families, len(report.Outcomes))
+	}
+}
+
+func TestVolume(t *testing.T) {
+	t.Parallel()
+
+	want := []Volume{
+		{Day: time.Date(2024, 3, 1, 0, 0, 0, 0, cet), Category: "movies", Count: 1, Bytes: 6 << 30},
+		{Day: time.Date(2024, 3, 1, 0, 0, 0, 0, cet), Category: "tv", Count: 1, Bytes: 3 << 30},
+		{Day: time.Date(2024, 3, 2, 0, 0, 0, 0, cet), Category: "movies", Count: 1},
+		{Day: time.Date(2024, 3, 2, 0, 0, 0, 0, cet), Category: "tv", Count: 1, Bytes: 500 << 20}, // 23:30 UTC.
+	}
+
+	volume := New(sampleHistory(), cet).Volume
+	if len(volume) != len(want) {
+		t.Fatalf("got %d volume rows, want %d", len(volume), len(want))
+	}
+
+	for idx, got := range volume {
+		if !got.Day.Equal(want[idx].Day) || got.Category != want[idx].Category ||
+			got.Count != want[idx].Count || got.Bytes != want[idx].Bytes {
+			t.Errorf("row %d: got %+v, want %+v", idx, *got, want[idx])
+		}
+	}
+}
+
+func TestTimings(t *testing.T) {
+	t.Parallel()
+
+	// Records that skipped a stage do not lower its average.
+	want := map[string]struct {
+		count   int
+		total   int64
+		average float64
+	}{
+		"download": {count: 3, total: 1000, average: 1000.0 / 3},
+		"par":      {count: 2, total: 50, average: 25},
+		"repair":   {count: 1, total: 60, average: 60},
+		"unpack":   {count: 2, total: 160, average: 80},
+		"post":     {count: 3, total: 295, average: 295.0 / 3},
+	}
+
+	timings := New(sampleHistory(), cet).Timings
+	if len(timings) != len(want) {
+		t.Fatalf("got %d timings, want %d", len(timings), len(want))
+	}
+
+	for _, timing := range timings {
+		if w := want[timing.Stage]; timing.Count != w.count || timing.TotalSec != w.total || !near(timing.AverageSec, w.average) {
+			t.Errorf("%s: got %+v, want %+v", timing.Stage, *timing, w)
+		}
+	}
+
+	// No record reached the stage, so there is no average.
+	if timing := New(sampleHistory()[3:], cet).Timings[0]; timing.Count != 0 || timing.AverageSec != 0 {
+		t.Errorf("got %+v, want an empty stage", *timing)
+	}
+}
+
+func TestServers(t *testing.T) {
+	t.Parallel()
+
+	want := []Server{
+		{ServerID: 1, Success: 1890, Failed: 110, Ratio: 0.945},
+		{ServerID: 2, Success: 4050, Ratio: 1},
+	}
+
+	servers := New(sampleHistory(), cet).Servers
+	if len(servers) != len(want) {
+		t.Fatalf("got %d servers, want %d", len(servers), len(want))
+	}
+
+	for idx, got := range servers {
+		if got.ServerID != want[idx].ServerID || got.Success != want[idx].Success ||
+			got.Failed != want[idx].Failed || !near(got.Ratio, want[idx].Ratio) {
+			t.Errorf("got %+v, want %+v", *got, want[idx])
+		}
+	}
+}
+
+func TestFromArchive(t *testing.T) {
+	t.Parallel()
+
+	store, err := archiver.OpenStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = store.Add(sampleHistory()); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := FromArchive(store, &nzbget.HistoryFilter{Categories: []string{"tv"}}, cet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Count != 2 || report.Bytes != 3<<30+500<<20 || !near(report.Rate("FAILURE"), 0.5) {
+		t.Errorf("got %d records, %d bytes, failure rate %v", report.Count, report.Bytes, report.Rate("FAILURE"))
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := New(sampleHistory(), cet).WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "report.txt", buf.Bytes())
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := New(sampleHistory(), cet).WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "report.csv", buf.Bytes())
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := New(sampleHistory(), cet).WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "report.json", buf.Bytes())
+}
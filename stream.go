@@ -0,0 +1,79 @@
+package nzbget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// GetEach makes a JSON-RPC request for a method that returns a list, and calls each
+// for every element as it is decoded. Only one element is held in memory at a time.
+// Return false from each to stop reading the response. Example:
+//
+//	err := nzbget.GetEach(ctx, client, "listfiles", func(file *nzbget.File) bool { ... }, 0, 0, nzbID)
+func GetEach[T any](ctx context.Context, n *NZBGet, method string, each func(*T) bool, args ...interface{}) error {
+	return n.GetInto(ctx, method, &listStream[T]{each: each}, args...)
+}
+
+// HistoryEach calls each for every item in the NZBGet Download History, as it is decoded.
+// Return false from each to stop reading the history.
+// https://nzbget.net/api/history
+func (n *NZBGet) HistoryEach(ctx context.Context, hidden bool, each func(*History) bool) error {
+	return GetEach(ctx, n, "history", each, hidden)
+}
+
+// ListGroupsEach calls each for every group in the NZBGet Download list, as it is decoded.
+// Return false from each to stop reading the list.
+// https://nzbget.net/api/listgroups
+func (n *NZBGet) ListGroupsEach(ctx context.Context, each func(*Group) bool) error {
+	return GetEach(ctx, n, "listgroups", each, 0)
+}
+
+// ListFilesEach calls each for every NZBGet File in a download, as it is decoded.
+// nzbID is the NZBID of the group to be returned. Use 0 for all file groups.
+// Return false from each to stop reading the list.
+// https://nzbget.net/api/listfiles
+func (n *NZBGet) ListFilesEach(ctx context.Context, nzbID int64, each func(*File) bool) error {
+	return GetEach(ctx, n, "listfiles", each, 0, 0, nzbID)
+}
+
+// LogEach calls each for every NZBGet log entry, as it is decoded.
+// NOTE: only one parameter - either startID or limit - can be specified. The other parameter must be 0.
+// Return false from each to stop reading the log.
+// https://nzbget.net/api/log
+func (n *NZBGet) LogEach(ctx context.Context, startID, limit int64, each func(*LogEntry) bool) error {
+	return GetEach(ctx, n, "log", each, startID, limit)
+}
+
+// listStream decodes a list result one element at a time.
+type listStream[T any] struct {
+	each func(*T) bool
+}
+
+func (l *listStream[T]) decodeResult(dec *json.Decoder) error {
+	token, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("decoding result: %w", err)
+	} else if token == nil {
+		return ErrNullResult
+	} else if token != json.Delim('[') {
+		return fmt.Errorf("%w: expected list, got %v", ErrInvalidResponse, token)
+	}
+
+	for dec.More() {
+		item := new(T)
+		if err := dec.Decode(item); err != nil {
+			return fmt.Errorf("decoding result: %w", err)
+		}
+
+		if !l.each(item) {
+			return errStopStream
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("decoding result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build go1.23
+
+package nzbget
+
+import (
+	"context"
+	"iter"
+)
+
+// GetSeq makes a JSON-RPC request for a method that returns a list, and returns an iterator
+// over the elements as they are decoded. The request is made when iteration starts.
+// A request or decoding error is yielded once, with a nil element, and ends the iteration.
+func GetSeq[T any](ctx context.Context, n *NZBGet, method string, args ...interface{}) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		stopped := false
+
+		err := GetEach(ctx, n, method, func(item *T) bool {
+			stopped = !yield(item, nil)
+			return !stopped
+		}, args...)
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// HistorySeq returns an iterator over the NZBGet Download History.
+// https://nzbget.net/api/history
+func (n *NZBGet) HistorySeq(ctx context.Context, hidden bool) iter.Seq2[*History, error] {
+	return GetSeq[History](ctx, n, "history", hidden)
+}
+
+// ListGroupsSeq returns an iterator over the NZBGet Download list.
+// https://nzbget.net/api/listgroups
+func (n *NZBGet) ListGroupsSeq(ctx context.Context) iter.Seq2[*Group, error] {
+	return GetSeq[Group](ctx, n, "listgroups", 0)
+}
+
+// ListFilesSeq returns an iterator over the NZBGet Files for a download.
+// nzbID is the NZBID of the group to be returned. Use 0 for all file groups.
+// https://nzbget.net/api/listfiles
+func (n *NZBGet) ListFilesSeq(ctx context.Context, nzbID int64) iter.Seq2[*File, error] {
+	return GetSeq[File](ctx, n, "listfiles", 0, 0, nzbID)
+}
@@ -0,0 +1,242 @@
+// Package stuck detects queued NZBGet downloads that stopped making progress,
+// classifies why they stalled, and optionally acts on them with EditQueue.
+package stuck
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golift.io/nzbget"
+	"golift.io/nzbget/internal/poll"
+)
+
+// Package defaults.
+const (
+	DefaultInterval = time.Minute
+	DefaultWindow   = 30 * time.Minute
+)
+
+// Reason classifies a stalled download. The group Status picks the Reason, and these
+// fields must stay unchanged for the Window before a download is stuck:
+//   - NO_CONNECTIONS, PAUSED_FILES, NO_PROGRESS: the downloaded size (DownloadedSizeHi/Lo).
+//   - PAR_REPAIR_SLOW, UNPACK_SLOW, SCRIPT_HANG: PostStageProgress. PostStageTimeSec going
+//     backwards means a new stage (or script) started, which counts as progress. A download
+//     first seen with no stage progress is stalled since its stage started PostStageTimeSec ago.
+//   - POST_QUEUED: only the Status, because nothing else changes while waiting.
+//
+// Status changes always count as progress. Paused downloads, and downloads waiting for
+// a paused queue, are never stuck.
+type Reason string
+
+// Reasons go here.
+const (
+	ReasonNoConnections  Reason = "NO_CONNECTIONS"  // downloading, but no news servers are enabled or connected.
+	ReasonPausedFiles    Reason = "PAUSED_FILES"    // downloading, but every remaining file is paused.
+	ReasonNoProgress     Reason = "NO_PROGRESS"     // downloading with active connections, but nothing arrives.
+	ReasonParRepairSlow  Reason = "PAR_REPAIR_SLOW" // par-check or repair is not progressing.
+	ReasonUnpackSlow     Reason = "UNPACK_SLOW"     // unpacking, renaming or moving is not progressing.
+	ReasonScriptHang     Reason = "SCRIPT_HANG"     // a post-processing script is not finishing.
+	ReasonPostProcessing Reason = "POST_QUEUED"     // waiting for post-processing that is not starting.
+)
+
+// Client is the part of *nzbget.NZBGet the Detector uses.
+type Client interface {
+	StatusContext(ctx context.Context) (*nzbget.Status, error)
+	ListGroupsContext(ctx context.Context) ([]*nzbget.Group, error)
+	EditQueueContext(ctx context.Context, command, parameter string, ids []int64) (bool, error)
+}
+
+// Config is the input data needed to return a Detector.
+type Config struct {
+	Interval time.Duration     // default: DefaultInterval
+	Window   time.Duration     // time without progress before a download is stuck, default: DefaultWindow
+	Actions  map[Reason]string // optional EditQueue command per reason, like GroupPause or PostDelete.
+	OnStall  func(*Stall)      // optional, called for every new stall.
+	Logger   nzbget.Logger     // optional, default: log.Default()
+}
+
+// Stall is a download that has not made progress for the configured Window.
+type Stall struct {
+	Group  *nzbget.Group
+	Reason Reason
+	Since  time.Time // last time progress was seen.
+	Action string    // EditQueue command issued, if any.
+	Error  string    // error from the action, if any.
+}
+
+// Detector tracks download progress across polls.
+type Detector struct {
+	client Client
+	config *Config
+	mu     sync.Mutex
+	seen   map[int64]*progress
+}
+
+// progress is the last observed state of a download.
+type progress struct {
+	status     nzbget.GroupStatus
+	downloaded int64
+	postStage  int64
+	postTime   int64 // PostStageTimeSec.
+	changed    time.Time
+	flagged    bool
+}
+
+// New returns a Detector.
+func New(client Client, config *Config) *Detector {
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+
+	if config.Window <= 0 {
+		config.Window = DefaultWindow
+	}
+
+	if config.Logger == nil {
+		config.Logger = log.Default()
+	}
+
+	return &Detector{client: client, config: config, seen: make(map[int64]*progress)}
+}
+
+// Run checks the queue immediately, and then every Interval until the context ends.
+// Errors are logged, and do not stop the detector.
+func (d *Detector) Run(ctx context.Context) {
+	poll.Run(ctx, d.config.Interval, d.config.Logger, "stuck detector", func(ctx context.Context) error {
+		_, err := d.Check(ctx)
+		return err
+	})
+}
+
+// Check polls NZBGet, returns downloads that became stuck since the last check,
+// and applies the configured action for each.
+func (d *Detector) Check(ctx context.Context) ([]*Stall, error) {
+	status, err := d.client.StatusContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting status: %w", err)
+	}
+
+	groups, err := d.client.ListGroupsContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting groups: %w", err)
+	}
+
+	stalls := d.Observe(time.Now(), status, groups)
+
+	for _, stall := range stalls {
+		if stall.Action = d.config.Actions[stall.Reason]; stall.Action != "" {
+			_, err := d.client.EditQueueContext(ctx, stall.Action, "", []int64{stall.Group.NZBID})
+			if err != nil {
+				stall.Error = err.Error()
+			}
+		}
+
+		d.config.Logger.Printf("stuck detector: %s (%d) %s since %v, action: %s %s",
+			stall.Group.NZBName, stall.Group.NZBID, stall.Reason, stall.Since.Round(time.Second), stall.Action, stall.Error)
+
+		if d.config.OnStall != nil {
+			d.config.OnStall(stall)
+		}
+	}
+
+	return stalls, nil
+}
+
+// Observe records one poll of NZBGet, and returns downloads that became stuck.
+// Each stall is returned once; it is returned again only after the download makes progress and stalls again.
+// Use this instead of Check to feed the detector data you already have.
+func (d *Detector) Observe(now time.Time, status *nzbget.Status, groups []*nzbget.Group) []*Stall {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stalls := []*Stall{}
+	current := make(map[int64]*progress, len(groups))
+
+	for _, group := range groups {
+		latest := &progress{
+			status:     group.Status,
+			downloaded: nzbget.JoinSize(group.DownloadedSizeHi, group.DownloadedSizeLo),
+			postStage:  group.PostStageProgress,
+			postTime:   group.PostStageTimeSec,
+			changed:    now,
+		}
+
+		if last := d.seen[group.NZBID]; last == nil && group.PostStageProgress == 0 && group.PostStageTimeSec > 0 {
+			// No progress since the stage started, possibly before the detector did.
+			latest.changed = now.Add(-time.Duration(group.PostStageTimeSec) * time.Second)
+		} else if last != nil && last.same(latest) {
+			latest.changed, latest.flagged = last.changed, last.flagged
+		}
+
+		current[group.NZBID] = latest
+
+		reason := classify(status, group)
+		if reason == "" {
+			latest.changed, latest.flagged = now, false // paused or waiting in line; not stuck.
+			continue
+		}
+
+		if !latest.flagged && now.Sub(latest.changed) >= d.config.Window {
+			latest.flagged = true
+			stalls = append(stalls, &Stall{Group: group, Reason: reason, Since: latest.changed})
+		}
+	}
+
+	d.seen = current // forget groups that left the queue.
+
+	return stalls
+}
+
+// same returns true if no progress was made between two observations.
+// A lower stage time means a new post-processing stage started.
+func (p *progress) same(latest *progress) bool {
+	return p.status == latest.status && p.downloaded == latest.downloaded &&
+		p.postStage == latest.postStage && p.postTime <= latest.postTime
+}
+
+// classify returns why a group could be stuck, or an empty reason if it is not expected to progress.
+func classify(status *nzbget.Status, group *nzbget.Group) Reason {
+	switch group.Status {
+	case nzbget.GroupDOWNLOADING:
+		switch {
+		case status.DownloadPaused:
+			return ""
+		case group.RemainingSizeMB > 0 && group.RemainingSizeMB == group.PausedSizeMB:
+			return ReasonPausedFiles
+		case !activeServers(status) || group.ActiveDownloads == 0:
+			return ReasonNoConnections
+		default:
+			return ReasonNoProgress
+		}
+	case nzbget.GroupPPQUEUED:
+		if status.PostPaused {
+			return ""
+		}
+
+		return ReasonPostProcessing
+	case nzbget.GroupLOADINGPARS, nzbget.GroupVERIFYINGSOURCES, nzbget.GroupREPAIRING, nzbget.GroupVERIFYINGREPAIRED:
+		return ReasonParRepairSlow
+	case nzbget.GroupRENAMING, nzbget.GroupUNPACKING, nzbget.GroupMOVING:
+		return ReasonUnpackSlow
+	case nzbget.GroupEXECUTINGSCRIPT:
+		return ReasonScriptHang
+	case nzbget.GroupQUEUED, nzbget.GroupPAUSED, nzbget.GroupFETCHING, nzbget.GroupPPFINISHED:
+		fallthrough
+	default:
+		return ""
+	}
+}
+
+// activeServers returns true if any news server is active.
+func activeServers(status *nzbget.Status) bool {
+	for _, server := range status.NewsServers {
+		if server.Active {
+			return true
+		}
+	}
+
+	return len(status.NewsServers) == 0
+}
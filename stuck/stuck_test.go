@@ -0,0 +1,60 @@
+package stuck
+
+import (
+	"testing"
+	"time"
+
+	"golift.io/nzbget"
+)
+
+func TestObservePostStage(t *testing.T) {
+	t.Parallel()
+
+	detector := New(nil, &Config{Window: 10 * time.Minute})
+	status := &nzbget.Status{}
+	start := time.Now()
+	group := &nzbget.Group{NZBID: 1, Status: nzbget.GroupEXECUTINGSCRIPT, PostStageTimeSec: 60}
+
+	if stalls := detector.Observe(start, status, []*nzbget.Group{group}); len(stalls) != 0 {
+		t.Fatalf("script running for a minute is stuck: %v", stalls[0].Reason)
+	}
+
+	// A second script started: its stage time went backwards, which is progress.
+	group.PostStageTimeSec = 5
+	if stalls := detector.Observe(start.Add(9*time.Minute), status, []*nzbget.Group{group}); len(stalls) != 0 {
+		t.Fatal("new script is stuck")
+	}
+
+	group.PostStageTimeSec = 600
+	if stalls := detector.Observe(start.Add(18*time.Minute), status, []*nzbget.Group{group}); len(stalls) != 0 {
+		t.Fatal("script is stuck before the window passed since it started")
+	}
+
+	group.PostStageTimeSec = 660
+	stalls := detector.Observe(start.Add(19*time.Minute), status, []*nzbget.Group{group})
+
+	if len(stalls) != 1 || stalls[0].Reason != ReasonScriptHang {
+		t.Fatalf("expected a script hang, got %d stalls", len(stalls))
+	}
+}
+
+func TestObserveStageTimeOnFirstSight(t *testing.T) {
+	t.Parallel()
+
+	detector := New(nil, &Config{Window: 10 * time.Minute})
+	now := time.Now()
+	groups := []*nzbget.Group{
+		{NZBID: 1, Status: nzbget.GroupUNPACKING, PostStageTimeSec: 3600},
+		{NZBID: 2, Status: nzbget.GroupUNPACKING, PostStageTimeSec: 3600, PostStageProgress: 500},
+	}
+
+	// Group 1 made no progress in the hour its stage has run. Group 2 did, at some point.
+	stalls := detector.Observe(now, &nzbget.Status{}, groups)
+	if len(stalls) != 1 || stalls[0].Group.NZBID != 1 || stalls[0].Reason != ReasonUnpackSlow {
+		t.Fatalf("expected group 1 to be stuck unpacking, got %d stalls", len(stalls))
+	}
+
+	if since := now.Sub(stalls[0].Since); since != time.Hour {
+		t.Errorf("stalled for %v, want 1h", since)
+	}
+}
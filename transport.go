@@ -0,0 +1,133 @@
+package nzbget
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// unixPrefix is the URL scheme used to connect to NZBGet over a unix socket.
+const unixPrefix = "unix://"
+
+// Errors returned while building an http.Client from a Config.
+var (
+	ErrInvalidTLSVersion = errors.New("invalid TLS version")
+	ErrNoCACerts         = errors.New("no certificates found in CA file")
+	ErrMissingKeyPair    = errors.New("client certificate and key must be provided together")
+	ErrSocketClient      = errors.New("unix socket URLs can not be used with a custom Client")
+)
+
+// tlsVersions maps config file TLS versions to their crypto/tls values.
+var tlsVersions = map[string]uint16{ //nolint:gochecknoglobals
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// HTTPClient returns the http.Client New() uses when Config.Client is nil.
+// It applies the Timeout, TLS and dialer settings from the Config. Call this
+// directly to validate the TLS settings, or to customize the client further.
+func (c *Config) HTTPClient() (*http.Client, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport, _ := http.DefaultTransport.(*http.Transport)
+	transport = transport.Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	if dial := c.dialer(); dial != nil {
+		transport.DialContext = dial
+	}
+
+	// Proxy environment variables must not send socket requests to a TCP proxy.
+	if c.socketPath() != "" {
+		transport.Proxy = nil
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// TLSConfig returns a tls.Config built from the TLS settings in the Config.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.Insecure, //nolint:gosec // the user asked for this.
+	}
+
+	if c.TLSMinVersion != "" {
+		version, ok := tlsVersions[c.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTLSVersion, c.TLSMinVersion)
+		}
+
+		tlsConfig.MinVersion = version
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: %s", ErrNoCACerts, c.CAFile)
+		}
+	}
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, ErrMissingKeyPair
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// socketPath returns the unix socket path from the URL, if it is a unix URL.
+func (c *Config) socketPath() string {
+	if !strings.HasPrefix(c.URL, unixPrefix) {
+		return ""
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(c.URL, unixPrefix), "/jsonrpc")
+}
+
+// dialer returns the DialContext function for the http transport.
+// Unix sockets pass the socket path to the custom dialer, if one is provided.
+func (c *Config) dialer() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	socket := c.socketPath()
+	if socket == "" {
+		return c.DialContext
+	}
+
+	dial := c.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dial(ctx, "unix", socket)
+	}
+}
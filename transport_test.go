@@ -0,0 +1,241 @@
+package nzbget
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a generated certificate and key, written to PEM files.
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// newTestCert generates a certificate for 127.0.0.1 and nzbget.test, signed by parent,
+// or self-signed if parent is nil. The certificate may sign others.
+func newTestCert(t *testing.T, name string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              []string{"nzbget.test"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	output := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(t.TempDir(), name+".crt"),
+		keyFile:  filepath.Join(t.TempDir(), name+".key"),
+	}
+
+	writePEM(t, output.certFile, "CERTIFICATE", der)
+	writePEM(t, output.keyFile, "EC PRIVATE KEY", keyDER)
+
+	return output
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTLSServer returns an NZBGet version endpoint served with a certificate signed by ca.
+// If clientCA is not nil, clients must present a certificate it signed.
+func newTLSServer(t *testing.T, ca, clientCA *testCert) *httptest.Server {
+	t.Helper()
+
+	serverCert := newTestCert(t, "server", ca)
+	pair, err := tls.LoadX509KeyPair(serverCert.certFile, serverCert.keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"1.1","result":"21.1"}`))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12}
+
+	if clientCA != nil {
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+		server.TLS.ClientCAs = x509.NewCertPool()
+		server.TLS.ClientCAs.AddCert(clientCA.cert)
+	}
+
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	cert := newTestCert(t, "client", nil)
+	other := newTestCert(t, "other", nil)
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+
+	if err := os.WriteFile(empty, []byte("not a certificate\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config *Config
+		err    error
+	}{
+		{name: "valid", config: &Config{TLSMinVersion: "1.2", CAFile: cert.certFile, CertFile: cert.certFile, KeyFile: cert.keyFile}},
+		{name: "tls version", config: &Config{TLSMinVersion: "1.4"}, err: ErrInvalidTLSVersion},
+		{name: "no ca certs", config: &Config{CAFile: empty}, err: ErrNoCACerts},
+		{name: "missing ca file", config: &Config{CAFile: empty + ".missing"}, err: os.ErrNotExist},
+		{name: "cert without key", config: &Config{CertFile: cert.certFile}, err: ErrMissingKeyPair},
+		{name: "key without cert", config: &Config{KeyFile: cert.keyFile}, err: ErrMissingKeyPair},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := test.config.HTTPClient(); !errors.Is(err, test.err) {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+		})
+	}
+
+	// A key that does not match the certificate fails to load.
+	if _, err := (&Config{CertFile: cert.certFile, KeyFile: other.keyFile}).TLSConfig(); err == nil {
+		t.Error("expected an error for a certificate and key that do not match")
+	}
+
+	// Requests made with an invalid config return the config error.
+	client := New(&Config{URL: "https://127.0.0.1:1", TLSMinVersion: "1.4"})
+	if _, err := client.VersionContext(context.Background()); !errors.Is(err, ErrInvalidTLSVersion) {
+		t.Errorf("expected requests to fail with ErrInvalidTLSVersion, got: %v", err)
+	}
+}
+
+func TestTLSVerify(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCert(t, "ca", nil)
+	server := newTLSServer(t, ca, nil)
+	tests := []struct {
+		name   string
+		config Config
+		ok     bool
+	}{
+		{name: "ca file", config: Config{CAFile: ca.certFile}, ok: true},
+		{name: "unknown ca", config: Config{}},
+		{name: "insecure", config: Config{Insecure: true}, ok: true},
+		{name: "server name", config: Config{CAFile: ca.certFile, ServerName: "nzbget.test"}, ok: true},
+		{name: "wrong server name", config: Config{CAFile: ca.certFile, ServerName: "other.test"}},
+		{name: "tls 1.3", config: Config{CAFile: ca.certFile, TLSMinVersion: "1.3"}, ok: true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.config.URL = server.URL
+			version, err := New(&test.config).VersionContext(context.Background())
+
+			if test.ok && (err != nil || version != "21.1") {
+				t.Errorf("got %q, %v; want 21.1", version, err)
+			} else if !test.ok && err == nil {
+				t.Error("expected certificate verification to fail")
+			}
+		})
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCert(t, "ca", nil)
+	clientCA := newTestCert(t, "client-ca", nil)
+	client := newTestCert(t, "client", clientCA)
+	server := newTLSServer(t, ca, clientCA)
+
+	config := &Config{URL: server.URL, CAFile: ca.certFile, CertFile: client.certFile, KeyFile: client.keyFile}
+	if version, err := New(config).VersionContext(context.Background()); err != nil || version != "21.1" {
+		t.Fatalf("with client certificate: got %q, %v", version, err)
+	}
+
+	config = &Config{URL: server.URL, CAFile: ca.certFile}
+	if _, err := New(config).VersionContext(context.Background()); err == nil {
+		t.Fatal("expected the server to refuse a client without a certificate")
+	}
+}
+
+func TestSocketWithCustomClient(t *testing.T) {
+	t.Parallel()
+
+	client := New(&Config{URL: "unix:///tmp/nzbget.sock", Client: &http.Client{}})
+	if _, err := client.VersionContext(context.Background()); !errors.Is(err, ErrSocketClient) {
+		t.Fatalf("expected ErrSocketClient, got: %v", err)
+	}
+}
+
+func TestSocketIgnoresProxy(t *testing.T) {
+	t.Parallel()
+
+	httpClient, err := (&Config{URL: "unix:///tmp/nzbget.sock"}).HTTPClient()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if transport, _ := httpClient.Transport.(*http.Transport); transport.Proxy != nil {
+		t.Fatal("socket transport must not use a proxy")
+	}
+
+	httpClient, _ = (&Config{URL: "http://localhost:6789"}).HTTPClient()
+	if transport, _ := httpClient.Transport.(*http.Transport); transport.Proxy == nil {
+		t.Fatal("TCP transport should use the environment proxy")
+	}
+}
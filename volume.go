@@ -0,0 +1,151 @@
+package nzbget
+
+import (
+	"time"
+)
+
+// VolumeSample is the amount of data downloaded during one ServerVolume time slot.
+type VolumeSample struct {
+	Time  time.Time // Start of the slot.
+	Bytes int64     // Amount of data downloaded during the slot.
+}
+
+// QuotaProjection is the download usage in a billing period, and whether it will exceed a quota.
+type QuotaProjection struct {
+	ServerID   int64
+	Start      time.Time // Start of the billing period.
+	End        time.Time // Start of the next billing period.
+	Used       int64     // Bytes downloaded so far in the period.
+	Quota      int64     // Bytes allowed in the period.
+	Projected  int64     // Bytes projected by the end of the period at the average rate so far.
+	Exceeded   bool      // Used is at or over Quota.
+	WillExceed bool      // Projected is at or over Quota.
+	ExceedTime time.Time // When Used is projected to reach Quota, if it will.
+}
+
+// Seconds returns the per-second amounts downloaded in the last 60 seconds, oldest first.
+func (v *ServerVolume) Seconds() []*VolumeSample {
+	return unroll(v.BytesPerSeconds, v.SecSlot, v.DataTime.Truncate(time.Second), time.Second)
+}
+
+// Minutes returns the per-minute amounts downloaded in the last 60 minutes, oldest first.
+func (v *ServerVolume) Minutes() []*VolumeSample {
+	return unroll(v.BytesPerMinutes, v.MinSlot, v.DataTime.Truncate(time.Minute), time.Minute)
+}
+
+// Hours returns the per-hour amounts downloaded in the last 24 hours, oldest first.
+func (v *ServerVolume) Hours() []*VolumeSample {
+	return unroll(v.BytesPerHours, v.HourSlot, v.DataTime.Truncate(time.Hour), time.Hour)
+}
+
+// Days returns the per-day amounts downloaded since the program was installed, oldest first.
+// NZBGet counts days in its own time zone; pass that zone as loc, or nil for time.Local.
+func (v *ServerVolume) Days(loc *time.Location) []*VolumeSample {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	output := make([]*VolumeSample, len(v.BytesPerDays))
+
+	for idx, day := range v.BytesPerDays {
+		output[idx] = &VolumeSample{
+			Time:  time.Date(1970, 1, 1+int(v.FirstDay)+idx, 0, 0, 0, 0, loc), //nolint:gomnd // the epoch.
+			Bytes: JoinSize(day.SizeHi, day.SizeLo),
+		}
+	}
+
+	return output
+}
+
+// Usage returns the bytes downloaded on the calendar days from start up to, but not including, end.
+// Day slots are the finest resolution covering more than 24 hours, so partial days count as whole days.
+func (v *ServerVolume) Usage(start, end time.Time) int64 {
+	loc := start.Location()
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	used := int64(0)
+
+	for _, day := range v.Days(loc) {
+		if !day.Time.Before(start) && day.Time.Before(end) {
+			used += day.Bytes
+		}
+	}
+
+	return used
+}
+
+// ProjectQuota returns the usage in the billing period containing now, and projects
+// whether quota bytes will be exceeded by the end of the period at the average rate so far.
+// billingDay is the day of the month the period starts. See BillingPeriod.
+func (v *ServerVolume) ProjectQuota(now time.Time, billingDay int, quota int64) *QuotaProjection {
+	start, end := BillingPeriod(now, billingDay)
+	used := v.Usage(start, end)
+	output := &QuotaProjection{
+		ServerID:  v.ServerID,
+		Start:     start,
+		End:       end,
+		Used:      used,
+		Quota:     quota,
+		Projected: used,
+		Exceeded:  quota > 0 && used >= quota,
+	}
+
+	if elapsed := now.Sub(start); elapsed > 0 {
+		rate := float64(used) / elapsed.Seconds()
+		output.Projected = int64(rate * end.Sub(start).Seconds())
+
+		if rate > 0 && quota > used {
+			output.ExceedTime = now.Add(time.Duration(float64(quota-used) / rate * float64(time.Second)))
+		}
+	}
+
+	output.WillExceed = quota > 0 && output.Projected >= quota
+
+	if output.Exceeded || !output.WillExceed {
+		output.ExceedTime = time.Time{}
+	}
+
+	return output
+}
+
+// BillingPeriod returns the start of the billing period containing now, and the start of
+// the next period. Periods start at midnight on day of the month, in the time zone of now.
+// Days past the end of a short month start the period on the last day of that month.
+func BillingPeriod(now time.Time, day int) (time.Time, time.Time) {
+	start := periodStart(now.Year(), now.Month(), day, now.Location())
+	if start.After(now) {
+		start = periodStart(now.Year(), now.Month()-1, day, now.Location())
+	}
+
+	return start, periodStart(start.Year(), start.Month()+1, day, now.Location())
+}
+
+// periodStart returns midnight on day of the month, or the last day of the month if day is past it.
+func periodStart(year int, month time.Month, day int, loc *time.Location) time.Time {
+	if day < 1 {
+		day = 1
+	}
+
+	// Day 0 of the next month is the last day of this month.
+	if last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day(); day > last {
+		day = last
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// unroll turns a ring buffer of slots into samples, oldest first. current is the slot being written,
+// and the start of that slot is at now. Each slot before it is one step earlier.
+func unroll(slots []BytesPer, current int64, now time.Time, step time.Duration) []*VolumeSample {
+	size := len(slots)
+	output := make([]*VolumeSample, size)
+
+	for idx := 0; idx < size; idx++ {
+		slot := (int(current) + 1 + idx) % size
+		output[idx] = &VolumeSample{
+			Time:  now.Add(-time.Duration(size-1-idx) * step),
+			Bytes: JoinSize(slots[slot].SizeHi, slots[slot].SizeLo),
+		}
+	}
+
+	return output
+}
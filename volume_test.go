@@ -0,0 +1,217 @@
+package nzbget
+
+import (
+	"testing"
+	"time"
+)
+
+// slots returns BytesPer slots holding the provided byte counts.
+func slots(sizes ...int64) []BytesPer {
+	output := make([]BytesPer, len(sizes))
+	for idx, size := range sizes {
+		output[idx] = BytesPer{SizeHi: size >> 32, SizeLo: size & 0xFFFFFFFF}
+	}
+
+	return output
+}
+
+func sampleBytes(samples []*VolumeSample) []int64 {
+	output := make([]int64, len(samples))
+	for idx, sample := range samples {
+		output[idx] = sample.Bytes
+	}
+
+	return output
+}
+
+func TestUnroll(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 15, 12, 34, 56, 789, time.UTC)
+	volume := &ServerVolume{
+		DataTime:        Time{now},
+		BytesPerSeconds: slots(10, 20, 30, 40),
+		BytesPerMinutes: slots(1, 2, 3),
+		BytesPerHours:   slots(5, 6, 7, 8, 9<<32+1),
+		SecSlot:         3, // the last slot is current, so the ring is in order.
+		MinSlot:         0, // the first slot is current, so the ring wraps.
+		HourSlot:        2,
+	}
+
+	tests := []struct {
+		name    string
+		samples []*VolumeSample
+		bytes   []int64
+		last    time.Time
+		step    time.Duration
+	}{
+		{name: "seconds", samples: volume.Seconds(), bytes: []int64{10, 20, 30, 40},
+			last: now.Truncate(time.Second), step: time.Second},
+		{name: "minutes wrapped", samples: volume.Minutes(), bytes: []int64{2, 3, 1},
+			last: time.Date(2024, 3, 15, 12, 34, 0, 0, time.UTC), step: time.Minute},
+		{name: "hours wrapped", samples: volume.Hours(), bytes: []int64{8, 9<<32 + 1, 5, 6, 7},
+			last: time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC), step: time.Hour},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := sampleBytes(test.samples); !sameIDs(got, test.bytes) {
+				t.Errorf("got bytes %v, want %v", got, test.bytes)
+			}
+
+			for idx, sample := range test.samples {
+				want := test.last.Add(-time.Duration(len(test.samples)-1-idx) * test.step)
+				if !sample.Time.Equal(want) {
+					t.Errorf("sample %d: got time %v, want %v", idx, sample.Time, want)
+				}
+			}
+		})
+	}
+
+	if samples := (&ServerVolume{DataTime: Time{now}}).Seconds(); len(samples) != 0 {
+		t.Errorf("got %d samples from no slots, want 0", len(samples))
+	}
+}
+
+func TestDays(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("CET", 3600)
+	volume := &ServerVolume{FirstDay: 19782, BytesPerDays: slots(100, 1<<33, 300)} // day 19782 is 2024-02-29.
+	days := volume.Days(loc)
+
+	if got := sampleBytes(days); !sameIDs(got, []int64{100, 1 << 33, 300}) {
+		t.Errorf("got bytes %v", got)
+	}
+
+	for idx, want := range []time.Time{
+		time.Date(2024, 2, 29, 0, 0, 0, 0, loc),
+		time.Date(2024, 3, 1, 0, 0, 0, 0, loc),
+		time.Date(2024, 3, 2, 0, 0, 0, 0, loc),
+	} {
+		if !days[idx].Time.Equal(want) {
+			t.Errorf("day %d: got %v, want %v", idx, days[idx].Time, want)
+		}
+	}
+
+	if day := volume.Days(nil)[0]; day.Time.Location() != time.Local {
+		t.Errorf("got location %v, want time.Local", day.Time.Location())
+	}
+}
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	volume := &ServerVolume{FirstDay: 19782, BytesPerDays: slots(100, 200, 300, 400)} // Feb 29 to Mar 3, 2024.
+	day := func(d int, hour int) time.Time { return time.Date(2024, 3, d, hour, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       int64
+	}{
+		{name: "all", start: day(-10, 0), end: day(10, 0), want: 1000},
+		{name: "one day", start: day(1, 0), end: day(2, 0), want: 200},
+		{name: "partial start day counts", start: day(1, 18), end: day(3, 0), want: 500},
+		{name: "end day excluded", start: day(1, 0), end: day(3, 0), want: 500},
+		{name: "partial end day counts", start: day(1, 0), end: day(3, 1), want: 900},
+		{name: "before install", start: day(-20, 0), end: day(-10, 0), want: 0},
+		{name: "empty range", start: day(2, 0), end: day(2, 0), want: 0},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := volume.Usage(test.start, test.end); got != test.want {
+				t.Errorf("got %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProjectQuota(t *testing.T) {
+	t.Parallel()
+
+	// 100 bytes a day for the first 10 days of March 2024.
+	volume := &ServerVolume{ServerID: 2, FirstDay: 19783, BytesPerDays: slots(100, 100, 100, 100, 100, 100, 100, 100, 100, 100)}
+	now := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		quota      int64
+		used       int64
+		projected  int64
+		exceeded   bool
+		willExceed bool
+		exceedTime time.Time
+	}{
+		{name: "no quota", quota: 0, used: 1000, projected: 3100},
+		{name: "under quota", quota: 5000, used: 1000, projected: 3100},
+		{name: "will exceed", quota: 2000, used: 1000, projected: 3100, willExceed: true,
+			exceedTime: time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{name: "exceeded", quota: 1000, used: 1000, projected: 3100, exceeded: true, willExceed: true},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := volume.ProjectQuota(now, 1, test.quota)
+			if got.ServerID != 2 || got.Used != test.used || got.Projected != test.projected ||
+				got.Exceeded != test.exceeded || got.WillExceed != test.willExceed || !got.ExceedTime.Equal(test.exceedTime) {
+				t.Errorf("got %+v", got)
+			}
+
+			if !got.Start.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || !got.End.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("got period %v to %v, want March", got.Start, got.End)
+			}
+		})
+	}
+
+	// At the very start of a period nothing has elapsed, so nothing is projected.
+	if got := volume.ProjectQuota(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1, 50); got.Projected != got.Used || !got.ExceedTime.IsZero() {
+		t.Errorf("start of period: got %+v", got)
+	}
+}
+
+func TestBillingPeriod(t *testing.T) {
+	t.Parallel()
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		day        int
+		start, end time.Time
+	}{
+		{name: "first of month", now: date(2024, 3, 15), day: 1, start: date(2024, 3, 1), end: date(2024, 4, 1)},
+		{name: "on the day", now: date(2024, 3, 15), day: 15, start: date(2024, 3, 15), end: date(2024, 4, 15)},
+		{name: "before the day", now: date(2024, 3, 14), day: 15, start: date(2024, 2, 15), end: date(2024, 3, 15)},
+		{name: "year wrap", now: date(2024, 1, 5), day: 10, start: date(2023, 12, 10), end: date(2024, 1, 10)},
+		{name: "day 31 in february", now: date(2023, 2, 15), day: 31, start: date(2023, 1, 31), end: date(2023, 2, 28)},
+		{name: "day 31 on feb 28", now: date(2023, 2, 28), day: 31, start: date(2023, 2, 28), end: date(2023, 3, 31)},
+		{name: "day 31 in leap february", now: date(2024, 2, 29), day: 31, start: date(2024, 2, 29), end: date(2024, 3, 31)},
+		{name: "day 31 in april", now: date(2024, 4, 30), day: 31, start: date(2024, 4, 30), end: date(2024, 5, 31)},
+		{name: "day 0", now: date(2024, 3, 15), day: 0, start: date(2024, 3, 1), end: date(2024, 4, 1)},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			if start, end := BillingPeriod(test.now, test.day); !start.Equal(test.start) || !end.Equal(test.end) {
+				t.Errorf("got %v to %v, want %v to %v", start, end, test.start, test.end)
+			}
+		})
+	}
+}